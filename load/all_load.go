@@ -36,7 +36,7 @@ func NewLoadFuncDataInfo() *LoadFuncDataInfo {
 
 func (lfi *LoadFuncDataInfo) LoadFuncDataInfoBytes(sqlComments []byte) error {
 	pkgName := getCurrentPackageName()
-	infos, err := loadCommentBytes(pkgName, sqlComments)
+	infos, err := loadCommentBytes(pkgName, "", sqlComments)
 	if err != nil {
 		return err
 	}
@@ -60,28 +60,25 @@ func (lfi *LoadFuncDataInfo) LoadFuncDataInfoString(sqlComments string) error {
 
 func (lfi *LoadFuncDataInfo) LoadFuncDataInfo(sqlDir embed.FS) error {
 	pkgName := getCurrentPackageName()
-	dirName := ""
 	files, err := sqlDir.ReadDir(".")
 	if err != nil {
 		return err
 	}
 	for _, fileInfo := range files {
-		if fileInfo.IsDir() {
-			dirName = fileInfo.Name() + "/"
+		// 只加载根目录下的go文件, 子目录(例如testdata)不加载
+		if fileInfo.IsDir() || !strings.HasSuffix(fileInfo.Name(), ".go") {
 			continue
 		}
-		if !fileInfo.IsDir() && strings.HasSuffix(fileInfo.Name(), ".go") {
-			bytes, err := sqlDir.ReadFile(dirName + fileInfo.Name())
-			if err != nil {
-				return err
-			}
-			infos, err := loadCommentBytes(pkgName, bytes)
-			if err != nil {
-				return err
-			}
-			for _, v := range infos {
-				lfi.sqlDataInfos[v.TypeName] = append(lfi.sqlDataInfos[v.TypeName], v)
-			}
+		bytes, err := sqlDir.ReadFile(fileInfo.Name())
+		if err != nil {
+			return err
+		}
+		infos, err := loadCommentBytes(pkgName, fileInfo.Name(), bytes)
+		if err != nil {
+			return err
+		}
+		for _, v := range infos {
+			lfi.sqlDataInfos[v.TypeName] = append(lfi.sqlDataInfos[v.TypeName], v)
 		}
 	}
 	return nil
@@ -16,14 +16,25 @@ type SqlDataInfo struct {
 	Sql         string
 	NotPrepare  bool
 	BatchInsert bool
+	DB          string
+	Shard       string
+	Strict      bool
+	Scan        string
+	RawSymbols  string
+	EmptyIn     string
+	ChunkParam  string
+	ChunkSize   string
 	Param       []string
+	Pos         token.Position // sql第一个字符在Go源文件中的位置
+
 }
 
-func loadCommentBytes(pkg string, bytes []byte) ([]*SqlDataInfo, error) {
+func loadCommentBytes(pkg, filename string, bytes []byte) ([]*SqlDataInfo, error) {
 	if bytes == nil {
 		return nil, errors.New("sql go bytes is nil")
 	}
-	astComment, err := parser.ParseFile(token.NewFileSet(), "", bytes, parser.ParseComments)
+	fset := token.NewFileSet()
+	astComment, err := parser.ParseFile(fset, filename, bytes, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
@@ -73,11 +84,32 @@ func loadCommentBytes(pkg string, bytes []byte) ([]*SqlDataInfo, error) {
 															sqlDataInfo.BatchInsert = v == "true"
 														case "name":
 															sqlDataInfo.Name = v
+														case "db":
+															sqlDataInfo.DB = v
+														case "shard":
+															sqlDataInfo.Shard = v
+														case "strict":
+															sqlDataInfo.Strict = v == "true"
+														case "scan":
+															sqlDataInfo.Scan = v
+														case "raw_symbols":
+															sqlDataInfo.RawSymbols = v
+														case "empty_in":
+															sqlDataInfo.EmptyIn = v
+														case "chunk_param":
+															sqlDataInfo.ChunkParam = v
+														case "chunk_size":
+															sqlDataInfo.ChunkSize = v
 														}
 													}
 												}
 											}
 										}
+										end := len(ci.Text)
+										if strings.HasPrefix(ci.Text, "/*") {
+											end -= 2
+										}
+										sqlDataInfo.Pos = sqlPosition(fset.Position(ci.Slash), ci.Text, end-len(sqlDataInfo.Sql))
 										for _, v := range fc.Params.List {
 											for _, v := range v.Names {
 												if len(v.Name) > 0 {
@@ -105,5 +137,18 @@ func loadCommentBytes(pkg string, bytes []byte) ([]*SqlDataInfo, error) {
 }
 
 func loadCommentString(pkg string, sqlComments string) ([]*SqlDataInfo, error) {
-	return loadCommentBytes(pkg, []byte(sqlComments))
+	return loadCommentBytes(pkg, "", []byte(sqlComments))
+}
+
+// sqlPosition 返回注释comment中从offset开始的sql在Go源文件中的位置
+func sqlPosition(pos token.Position, comment string, offset int) token.Position {
+	prefix := comment[:offset]
+	if i := strings.LastIndex(prefix, "\n"); i >= 0 {
+		pos.Line += strings.Count(prefix, "\n")
+		pos.Column = offset - i
+	} else {
+		pos.Column += offset
+	}
+	pos.Offset += offset
+	return pos
 }
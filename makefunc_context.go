@@ -3,12 +3,15 @@ package tgsql
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"reflect"
 
 	"github.com/tianxinzizhen/tgsql/load"
+	"github.com/tianxinzizhen/tgsql/sqlval"
 	"github.com/tianxinzizhen/tgsql/template"
+	"github.com/tianxinzizhen/tgsql/template/parse"
 )
 
 func handleParam(sqlInfo *load.SqlDataInfo, op *funcExecOption, args []reflect.Value) {
@@ -38,10 +41,11 @@ func handleParam(sqlInfo *load.SqlDataInfo, op *funcExecOption, args []reflect.V
 			}
 		}
 	}
-	if useMultiParam && len(sqlInfo.Param) > 0 {
+	// 分批查询按照chunk_param的名称引用切片参数, 只有一个切片参数时也使用参数名称
+	if (useMultiParam || sqlInfo.ChunkParam != "") && len(sqlInfo.Param) > 0 {
 		paramMap := map[string]any{}
 		for i, v := range sqlInfo.Param {
-			if reflect.ValueOf(opArgs[i]).Type().Implements(contextType) {
+			if args[i].Type().Implements(contextType) {
 				continue
 			}
 			paramMap[v] = opArgs[i]
@@ -50,7 +54,10 @@ func handleParam(sqlInfo *load.SqlDataInfo, op *funcExecOption, args []reflect.V
 	}
 }
 
-func makeDBFuncContext(t reflect.Type, tdb *TgenSql, action Operation, templateSql *template.Template, sqlInfo *load.SqlDataInfo) reflect.Value {
+func makeDBFuncContext(t reflect.Type, tdb *TgenSql, action Operation, templateSql *template.Template, sqlInfo *load.SqlDataInfo, dataSource string) reflect.Value {
+	scanMode, _ := sqlval.ParseScanMode(sqlInfo.Scan)
+	emptyIn, _ := ParseEmptyIn(sqlInfo.EmptyIn)
+	chunkIndex, chunkSize, _ := chunkOption(t, sqlInfo)
 	return reflect.MakeFunc(t, func(args []reflect.Value) (results []reflect.Value) {
 		var err error
 		var hasReturnErr bool
@@ -58,10 +65,16 @@ func makeDBFuncContext(t reflect.Type, tdb *TgenSql, action Operation, templateS
 			hasReturnErr = t.Out(t.NumOut() - 1).Implements(errorType)
 		}
 		op := &funcExecOption{
-			ctx: context.Background(), // default ctx
+			ctx:      context.Background(), // default ctx
+			daoType:  sqlInfo.TypeName,
+			funcName: sqlInfo.Name,
+			scanMode: scanMode,
+			emptyIn:  emptyIn,
+		}
+		results = make([]reflect.Value, t.NumOut())
+		for i := 0; i < t.NumOut(); i++ {
+			results[i] = reflect.Zero(t.Out(i))
 		}
-		// 处理参数
-		handleParam(sqlInfo, op, args)
 		handleErr := func() {
 			if hasReturnErr {
 				results[t.NumOut()-1] = reflect.ValueOf(funcErr(sqlInfo.FuncName, err))
@@ -70,16 +83,6 @@ func makeDBFuncContext(t reflect.Type, tdb *TgenSql, action Operation, templateS
 				panic(recoverLog(err))
 			}
 		}
-		if !GetEnableSqlTx(op.ctx) {
-			var conn *sql.Conn
-			conn, err = tdb.db.Conn(op.ctx)
-			if err != nil {
-				handleErr()
-				return results
-			}
-			defer conn.Close()
-			op.db = conn
-		}
 		if sqlInfo.NotPrepare {
 			op.option |= optionNotPrepare
 		}
@@ -87,135 +90,169 @@ func makeDBFuncContext(t reflect.Type, tdb *TgenSql, action Operation, templateS
 		if sqlInfo.BatchInsert {
 			op.option |= optionBatchInsert
 		}
-		results = make([]reflect.Value, t.NumOut())
-		for i := 0; i < t.NumOut(); i++ {
-			results[i] = reflect.Zero(t.Out(i))
+		op.result = results
+		if hasReturnErr {
+			op.result = results[:len(results)-1]
 		}
-		var changeOp func(op *funcExecOption) (bool, error)
-		if op.option&optionBatchInsert != 0 {
-			if action != execNoResultAction {
-				err = errors.New("batch insert only support exec no result action")
-				handleErr()
-				return results
+		// 分批查询时每批的结果追加到同一个切片中
+		for _, chunk := range chunkArgs(args, chunkIndex, chunkSize) {
+			// 处理参数
+			op.param = nil
+			handleParam(sqlInfo, op, chunk)
+			var targets []shardTarget
+			if sqlInfo.BatchInsert {
+				targets, err = tdb.shardBatchTargets(sqlInfo.Shard, op.param, dataSource)
+			} else {
+				targets, err = tdb.shardTargets(sqlInfo.Shard, action, op.param, dataSource)
+				// 分片规则可以在InitDBFunc之后注册, 执行时再检查一次
+				if err == nil && len(targets) > 1 {
+					err = fanOutResult(t, sqlInfo.Shard)
+				}
 			}
-			pv := reflect.ValueOf(op.param)
-			switch pv.Kind() {
-			case reflect.Slice:
-				if pv.Len() == 0 {
-					err = errors.New("batch insert param is empty")
+			if err == nil && GetEnableSqlTx(op.ctx) && !tdb.isDryRun(op.ctx) {
+				// 执行前检查所有分片, 避免只在部分分片上执行
+				for _, target := range targets {
+					if err = tdb.checkTxDataSource(target.dataSourceContext(op.ctx), target.dataSource); err != nil {
+						break
+					}
 				}
-			default:
-				err = errors.New("batch insert param type not support")
 			}
 			if err != nil {
 				handleErr()
 				return results
 			}
-			changeOp = func(op *funcExecOption) (bool, error) {
-				if op.offset < pv.Len() {
-					op.param = pv.Index(op.offset).Interface()
-					op.offset++
-					err = tdb.templateBuild(templateSql, op)
-					return true, nil
+			for _, target := range targets {
+				err = tdb.execDBFunc(op, action, templateSql, target)
+				if err != nil {
+					handleErr()
+					return results
 				}
-				return false, nil
 			}
-		} else {
-			err = tdb.templateBuild(templateSql, op)
 		}
-		if err != nil {
-			handleErr()
-			return results
-		}
-		switch action {
-		case execAction:
-			var ret sql.Result
-			ret, err = tdb.exec(op)
-			if err != nil {
-				handleErr()
-				return results
-			}
-			if ret != nil {
-				result := reflect.ValueOf(ret)
-				for i := 0; i < t.NumOut(); i++ {
-					if t.Out(i) == sqlResultType {
-						results[i] = result
-					}
+		if action == execAction && op.ret != nil {
+			result := reflect.ValueOf(op.ret)
+			for i := 0; i < t.NumOut(); i++ {
+				if t.Out(i) == sqlResultType {
+					results[i] = result
 				}
 			}
-		case selectAction:
-			op.result = results
-			if hasReturnErr {
-				op.result = results[:len(results)-1]
-			}
-			err = tdb.query(op)
-			if err != nil {
-				handleErr()
-				return results
-			}
-		case selectOneAction:
-			op.result = results
-			if hasReturnErr {
-				op.result = results[:len(results)-1]
+		}
+		return results
+	})
+}
+
+func (tdb *TgenSql) execDBFunc(op *funcExecOption, action Operation, templateSql *template.Template, target shardTarget) (err error) {
+	op.shardTable, op.shardSuffix = target.table, target.suffix
+	batchInsert := op.option&optionBatchInsert != 0
+	if !batchInsert {
+		// 先渲染sql, 空切片的in返回错误或者跳过执行时不获取数据库连接
+		err = tdb.templateBuild(templateSql, op)
+		if errors.Is(err, errSkipExec) {
+			if action == execAction {
+				op.ret = driver.RowsAffected(0)
 			}
-			err = tdb.queryOption(op, queryOption{selectOne: true})
-			if err != nil {
-				handleErr()
-				return results
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if target.param != nil {
+		op.param = target.param
+	}
+	if !GetEnableSqlTx(op.ctx) && !tdb.isDryRun(op.ctx) {
+		sqlDB, err := tdb.selectDB(target.dataSourceContext(op.ctx), target.dataSource)
+		if err != nil {
+			return err
+		}
+		conn, err := sqlDB.Conn(op.ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		op.db = conn
+	}
+	if batchInsert {
+		if action != execNoResultAction {
+			return errors.New("batch insert only support exec no result action")
+		}
+		pv := reflect.ValueOf(op.param)
+		switch pv.Kind() {
+		case reflect.Slice:
+			if pv.Len() == 0 {
+				return errors.New("batch insert param is empty")
 			}
-		case execNoResultAction:
-			if op.option&optionBatchInsert != 0 {
-				stmtMap := map[string]*sql.Stmt{}
-				var preSql string
-				defer func() {
-					for _, s := range stmtMap {
-						if s != nil {
-							s.Close()
-						}
+		default:
+			return errors.New("batch insert param type not support")
+		}
+		// 先渲染第一行, 拦截器中可以看到批量执行的语句
+		op.param = pv.Index(0).Interface()
+		err = tdb.templateBuild(templateSql, op)
+		op.param = pv.Interface()
+		if err != nil && !errors.Is(err, errSkipExec) {
+			return err
+		}
+		firstErr, firstSql, firstArgs := err, op.sql, op.args
+		return tdb.intercept(op, StmtBatch, func(info *StmtInfo) error {
+			stmtMap := map[string]*sql.Stmt{}
+			op.inBatch = true
+			defer func() {
+				op.inBatch = false
+				op.stmt = nil
+				for _, s := range stmtMap {
+					if s != nil {
+						s.Close()
 					}
-				}()
-				for {
-					var change bool
-					change, err = changeOp(op)
+				}
+				op.param = pv.Interface()
+			}()
+			for i := 0; i < pv.Len(); i++ {
+				op.param = pv.Index(i).Interface()
+				err := firstErr
+				if i == 0 {
+					op.sql, op.args = firstSql, firstArgs
+				} else {
+					err = tdb.templateBuild(templateSql, op)
+				}
+				if errors.Is(err, errSkipExec) {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				stmt, ok := stmtMap[op.sql]
+				if !ok {
+					op.stmt = nil
+					stmt, err = tdb.prepareContext(op)
 					if err != nil {
-						handleErr()
-						return results
-					}
-					if preSql != op.sql {
-						if _, ok := stmtMap[preSql]; !ok {
-							var stmt *sql.Stmt
-							stmt, err = tdb.prepareContext(op)
-							if err != nil {
-								handleErr()
-								return results
-							}
-							preSql = op.sql
-							stmtMap[preSql] = stmt
-							op.stmt = stmt
-						}
-					}
-					if stmt, ok := stmtMap[op.sql]; ok {
-						op.stmt = stmt
-						_, err = stmt.ExecContext(op.ctx, op.args...)
-						if err != nil {
-							handleErr()
-							return results
-						}
-					}
-					if !change {
-						break
+						return err
 					}
+					stmtMap[op.sql] = stmt
 				}
-			} else {
-				_, err = tdb.exec(op)
+				op.stmt = stmt
+				ret, err := tdb.exec(op)
 				if err != nil {
-					handleErr()
-					return results
+					return err
+				}
+				if n, err := ret.RowsAffected(); err == nil {
+					info.RowsAffected += n
 				}
+				info.BatchRows++
 			}
-		}
-		return results
-	})
+			return nil
+		})
+	}
+	switch action {
+	case execAction:
+		op.ret, err = tdb.exec(op)
+	case selectAction:
+		err = tdb.query(op)
+	case selectOneAction:
+		err = tdb.queryOption(op, queryOption{selectOne: true})
+	case execNoResultAction:
+		_, err = tdb.exec(op)
+	}
+	return err
 }
 
 func checkAllDBFuncSet(tdb *TgenSql, dv reflect.Value) error {
@@ -252,15 +289,21 @@ func InitDBFunc(tdb *TgenSql, dest any) error {
 	dt := dv.Type()
 	tp := template.New(dt.Name()).Delims(tdb.leftDelim, tdb.rightDelim).
 		Funcs(tdb.sqlFunc)
+	structDB := structDataSource(dt)
 	fkey := fmt.Sprintf("%s.%s", dt.PkgPath(), dt.Name())
 	sqlInfos := tdb.localFuncDataInfo.GetSqlDataInfo(fkey)
 	if len(sqlInfos) == 0 {
 		return fmt.Errorf("NewDBFunc not found sql script data in type %s", dt.Name())
 	}
 	for _, sqlInfo := range sqlInfos {
-		_, err := tp.AddParse(sqlInfo.Name, sqlInfo.Sql)
+		mode, err := parse.RawSymbolsMode(sqlInfo.RawSymbols)
 		if err != nil {
-			return err
+			return fmt.Errorf("NewDBFunc %s.%s %w", dt.Name(), sqlInfo.Name, err)
+		}
+		src := parse.Source{File: sqlInfo.Pos.Filename, Line: sqlInfo.Pos.Line, Col: sqlInfo.Pos.Column}
+		_, err = tp.AddParseMode(sqlInfo.Name, sqlInfo.Sql, mode, src)
+		if err != nil {
+			return fmt.Errorf("NewDBFunc %s.%s %w", dt.Name(), sqlInfo.Name, err)
 		}
 		if fc, ok := dt.FieldByName(sqlInfo.Name); ok {
 			t := tp.Lookup(sqlInfo.Name)
@@ -306,7 +349,28 @@ func InitDBFunc(tdb *TgenSql, dest any) error {
 						}
 					}
 				}
-				fcv.Set(makeDBFuncContext(fct, tdb, action, t, sqlInfo))
+				if _, err := sqlval.ParseScanMode(sqlInfo.Scan); err != nil {
+					return fmt.Errorf("NewDBFunc %s.%s %w", dt.Name(), sqlInfo.Name, err)
+				}
+				if _, err := ParseEmptyIn(sqlInfo.EmptyIn); err != nil {
+					return fmt.Errorf("NewDBFunc %s.%s %w", dt.Name(), sqlInfo.Name, err)
+				}
+				if _, _, err := chunkOption(fct, sqlInfo); err != nil {
+					return fmt.Errorf("NewDBFunc %s.%s %w", dt.Name(), sqlInfo.Name, err)
+				}
+				if err := tdb.shardFanOutOption(fct, sqlInfo); err != nil {
+					return fmt.Errorf("NewDBFunc %s.%s %w", dt.Name(), sqlInfo.Name, err)
+				}
+				if tdb.strict || sqlInfo.Strict {
+					if err := tdb.checkTemplate(dt, fct, sqlInfo, t); err != nil {
+						return err
+					}
+				}
+				dataSource := structDB
+				if sqlInfo.DB != "" {
+					dataSource = sqlInfo.DB
+				}
+				fcv.Set(makeDBFuncContext(fct, tdb, action, t, sqlInfo, dataSource))
 			}
 		}
 	}
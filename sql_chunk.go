@@ -0,0 +1,63 @@
+package tgsql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/tianxinzizhen/tgsql/load"
+)
+
+// defaultChunkSize 只设置chunk_param时每批的元素个数
+const defaultChunkSize = 1000
+
+// chunkOption 返回?option{chunk_param:ids,chunk_size:1000}中切片参数的位置和每批的元素个数, 没有设置时位置为-1
+func chunkOption(fct reflect.Type, sqlInfo *load.SqlDataInfo) (index, size int, err error) {
+	if sqlInfo.ChunkParam == "" {
+		if sqlInfo.ChunkSize != "" {
+			return -1, 0, errors.New("chunk_size option needs chunk_param")
+		}
+		return -1, 0, nil
+	}
+	size = defaultChunkSize
+	if sqlInfo.ChunkSize != "" {
+		size, err = strconv.Atoi(sqlInfo.ChunkSize)
+		if err != nil || size <= 0 {
+			return -1, 0, fmt.Errorf("chunk_size %s is not a positive integer", sqlInfo.ChunkSize)
+		}
+	}
+	index = slices.Index(sqlInfo.Param, sqlInfo.ChunkParam)
+	if index < 0 || index >= fct.NumIn() {
+		return -1, 0, fmt.Errorf("chunk_param %s not found", sqlInfo.ChunkParam)
+	}
+	if pt := fct.In(index); pt.Kind() != reflect.Slice || pt.Elem().Kind() == reflect.Uint8 {
+		return -1, 0, fmt.Errorf("chunk_param %s type %s is not slice", sqlInfo.ChunkParam, pt)
+	}
+	if fct.NumOut() == 0 || fct.Out(0).Kind() != reflect.Slice {
+		return -1, 0, errors.New("chunk_param option only support select func returning slice")
+	}
+	// 多个返回值时每批的结果会覆盖上一批, 只支持一个切片和error
+	for i := 1; i < fct.NumOut(); i++ {
+		if !fct.Out(i).Implements(errorType) {
+			return -1, 0, errors.New("chunk_param option only support select func returning one slice and error")
+		}
+	}
+	return index, size, nil
+}
+
+// chunkArgs 将第index个切片参数按照size拆分成多组参数, 不需要拆分时返回原参数
+func chunkArgs(args []reflect.Value, index, size int) [][]reflect.Value {
+	if index < 0 || args[index].Len() <= size {
+		return [][]reflect.Value{args}
+	}
+	list := args[index]
+	var chunks [][]reflect.Value
+	for i := 0; i < list.Len(); i += size {
+		chunk := slices.Clone(args)
+		chunk[index] = list.Slice(i, min(i+size, list.Len()))
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
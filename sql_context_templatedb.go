@@ -3,6 +3,8 @@ package tgsql
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"reflect"
 )
 
@@ -13,9 +15,7 @@ type SqlTemplate[T any] struct {
 }
 
 func (st SqlTemplate[T]) Query(tdb *TgenSql) (T, error) {
-	op := &funcExecOption{
-		db: tdb.db,
-	}
+	op := &funcExecOption{}
 	var result T
 	op.result = append(op.result, reflect.ValueOf(result))
 	op.ctx = st.Ctx
@@ -23,14 +23,14 @@ func (st SqlTemplate[T]) Query(tdb *TgenSql) (T, error) {
 	op.param = st.Param
 	if op.ctx == nil {
 		op.ctx = context.Background()
-	} else {
-		tx, ok := FromSqlTx(op.ctx)
-		if ok && tx != nil {
-			op.db = tx
-		}
 	}
-	var err error
-	op.sql, op.args, err = tdb.sqlTemplateBuild(op.ctx, op.sql, op.param)
+	if err := tdb.templateDB(op); err != nil {
+		return result, err
+	}
+	err := tdb.sqlTemplateBuild(op)
+	if errors.Is(err, errSkipExec) {
+		return result, nil
+	}
 	if err != nil {
 		return result, err
 	}
@@ -42,22 +42,20 @@ func (st SqlTemplate[T]) Query(tdb *TgenSql) (T, error) {
 }
 
 func (st SqlTemplate[T]) Exec(tdb *TgenSql) (sql.Result, error) {
-	op := &funcExecOption{
-		db: tdb.db,
-	}
+	op := &funcExecOption{}
 	op.ctx = st.Ctx
 	op.sql = st.Sql
 	op.param = st.Param
 	if op.ctx == nil {
 		op.ctx = context.Background()
-	} else {
-		tx, ok := FromSqlTx(op.ctx)
-		if ok && tx != nil {
-			op.db = tx
-		}
 	}
-	var err error
-	op.sql, op.args, err = tdb.sqlTemplateBuild(op.ctx, op.sql, op.param)
+	if err := tdb.templateDB(op); err != nil {
+		return nil, err
+	}
+	err := tdb.sqlTemplateBuild(op)
+	if errors.Is(err, errSkipExec) {
+		return driver.RowsAffected(0), nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -67,3 +65,23 @@ func (st SqlTemplate[T]) Exec(tdb *TgenSql) (sql.Result, error) {
 	}
 	return result, nil
 }
+
+// templateDB 选择SqlTemplate执行的数据库, 事务中使用上下文中的事务, dry-run时不需要数据库
+func (tdb *TgenSql) templateDB(op *funcExecOption) error {
+	if tdb.isDryRun(op.ctx) {
+		return nil
+	}
+	if tx, ok := FromSqlTx(op.ctx); ok && tx != nil {
+		if err := tdb.checkTxDataSource(op.ctx, ""); err != nil {
+			return err
+		}
+		op.db = tx
+		return nil
+	}
+	sqlDB, err := tdb.selectDB(op.ctx, "")
+	if err != nil {
+		return err
+	}
+	op.db = sqlDB
+	return nil
+}
@@ -0,0 +1,113 @@
+package tgsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+)
+
+// DataSource 结构体级别的数据源标记字段, 通过tag指定数据源名称
+//
+//	type OrderDB struct {
+//		_ tgsql.DataSource `db:"orders"`
+//	}
+type DataSource struct{}
+
+var dataSourceType = reflect.TypeFor[DataSource]()
+
+type dataSourceKey struct{}
+
+func NewDataSource(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, dataSourceKey{}, name)
+}
+
+func FromDataSource(ctx context.Context) (name string, ok bool) {
+	if ctx == nil {
+		return "", false
+	}
+	name, ok = ctx.Value(dataSourceKey{}).(string)
+	return
+}
+
+// AddDB 注册命名数据源, 可以和DAO方法的调用并发执行
+func (tdb *TgenSql) AddDB(name string, sqlDB *sql.DB) {
+	tdb.dbsMu.Lock()
+	defer tdb.dbsMu.Unlock()
+	if tdb.dbs == nil {
+		tdb.dbs = make(map[string]*sql.DB)
+	}
+	tdb.dbs[name] = sqlDB
+}
+
+func (tdb *TgenSql) GetDB(name string) (*sql.DB, bool) {
+	if name == "" {
+		return tdb.db, tdb.db != nil
+	}
+	tdb.dbsMu.RLock()
+	defer tdb.dbsMu.RUnlock()
+	sqlDB, ok := tdb.dbs[name]
+	return sqlDB, ok
+}
+
+var ErrTxDataSource = errors.New("data source is not the data source of the transaction")
+
+// dataSourceName 数据源选择顺序: 调用上下文 > 方法option > 结构体标记 > 默认数据源
+func dataSourceName(ctx context.Context, name string) string {
+	if ctxName, ok := FromDataSource(ctx); ok {
+		return ctxName
+	}
+	return name
+}
+
+func (tdb *TgenSql) selectDB(ctx context.Context, name string) (*sql.DB, error) {
+	name = dataSourceName(ctx, name)
+	sqlDB, ok := tdb.GetDB(name)
+	if !ok || sqlDB == nil {
+		return nil, fmt.Errorf("data source[%s] not registered", name)
+	}
+	return sqlDB, nil
+}
+
+func structDataSource(dt reflect.Type) string {
+	for i := 0; i < dt.NumField(); i++ {
+		if f := dt.Field(i); f.Type == dataSourceType {
+			return f.Tag.Get("db")
+		}
+	}
+	return ""
+}
+
+// RangeDB 遍历默认数据源和所有命名数据源, 默认数据源的名称为空字符串
+func (tdb *TgenSql) RangeDB(fn func(name string, sqlDB *sql.DB) bool) {
+	if tdb.db != nil && !fn("", tdb.db) {
+		return
+	}
+	tdb.dbsMu.RLock()
+	dbs := maps.Clone(tdb.dbs)
+	tdb.dbsMu.RUnlock()
+	for name, sqlDB := range dbs {
+		if !fn(name, sqlDB) {
+			return
+		}
+	}
+}
+
+// checkTxDataSource 事务中执行时方法的数据源必须是开启事务的数据源, 否则语句会在事务的数据库上执行.
+// 通过NewSqlTx传入的事务视为默认数据源的事务
+func (tdb *TgenSql) checkTxDataSource(ctx context.Context, name string) error {
+	sqlDB, err := tdb.selectDB(ctx, name)
+	if err != nil {
+		return err
+	}
+	txDB, ok := ctx.Value(sqlTxDBKey{}).(*sql.DB)
+	if !ok {
+		txDB = tdb.db
+	}
+	if txDB != nil && sqlDB != txDB {
+		return fmt.Errorf("data source[%s] %w", dataSourceName(ctx, name), ErrTxDataSource)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package tgsql
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/tianxinzizhen/tgsql/sqlval"
+	"github.com/tianxinzizhen/tgsql/sqlwrite"
+	"github.com/tianxinzizhen/tgsql/util"
+)
+
+type Dialect int
+
+const (
+	DialectMySQL Dialect = iota
+	DialectPostgres
+)
+
+// SetDialect 设置数据库方言, 在执行时生效, 可以在InitDBFunc之后调用, 但不能与执行并发调用.
+// PostgreSQL方言下参数占位符使用$1,$2..., in和any函数将切片作为一个数组参数绑定
+func (tdb *TgenSql) SetDialect(dialect Dialect) {
+	tdb.dialect = dialect
+}
+
+// dialectIn 模板中的in函数, 执行时按照方言选择
+func (tdb *TgenSql) dialectIn(list ...reflect.Value) *sqlwrite.SqlWrite {
+	if tdb.dialect == DialectPostgres {
+		return pgInParameter(list...)
+	}
+	return inParameter(list...)
+}
+
+// dialectAny 模板中的any函数, 执行时按照方言选择
+func (tdb *TgenSql) dialectAny(v reflect.Value) *sqlwrite.SqlWrite {
+	if tdb.dialect == DialectPostgres {
+		return pgAnyParameter(v)
+	}
+	return anyParameter(v)
+}
+
+// rebind 按照方言替换sql中的参数占位符
+func (tdb *TgenSql) rebind(sqw *sqlwrite.SqlWrite) string {
+	if tdb.dialect == DialectPostgres {
+		return sqw.Rebind(func(n int) string {
+			return "$" + strconv.Itoa(n)
+		})
+	}
+	return sqw.Sql()
+}
+
+// interpolate 按参数占位符的位置插值, 字符串和注释中的?以及转义的\?保持不变
+func (tdb *TgenSql) interpolate(sqw *sqlwrite.SqlWrite, args []any) (sql string, err error) {
+	sql = sqw.Rebind(func(n int) string {
+		if n > len(args) {
+			if err == nil {
+				err = fmt.Errorf("sql placeholder count is more than args(%d)", len(args))
+			}
+			return "?"
+		}
+		buf, e := util.AppendParam(nil, args[n-1], tdb.SqlEscapeBytesBackslash)
+		if e != nil && err == nil {
+			err = e
+		}
+		return string(buf)
+	})
+	if err != nil {
+		return "", err
+	}
+	return sql, nil
+}
+
+func isArrayParam(v reflect.Value) bool {
+	v, _ = util.Indirect(v)
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8
+}
+
+// anyParameter 切片输出in (?,?), 单个值输出= ?
+func anyParameter(v reflect.Value) *sqlwrite.SqlWrite {
+	sqw := &sqlwrite.SqlWrite{}
+	if !isArrayParam(v) {
+		sqw.WriteParam("= ? ", v.Interface())
+		return sqw
+	}
+	sqw.WriteString("in (")
+	sqw.WriteParam("", inParameter(v))
+	sqw.WriteString(") ")
+	return sqw
+}
+
+// pgAnyParameter 切片作为一个数组参数输出= ANY(?)
+func pgAnyParameter(v reflect.Value) *sqlwrite.SqlWrite {
+	sqw := &sqlwrite.SqlWrite{}
+	if !isArrayParam(v) {
+		sqw.WriteParam("= ? ", v.Interface())
+		return sqw
+	}
+	sqw.WriteParam("= ANY(? ) ", sqlval.Array{V: v.Interface()})
+	markEmptyArray(sqw, v)
+	return sqw
+}
+
+// pgInParameter 只有一个切片参数时输出select unnest(CAST(? AS type[])), 用在in (...)中只绑定一个数组参数,
+// unnest不能从未知类型的参数推断数组类型, 需要按照切片元素类型转换
+func pgInParameter(list ...reflect.Value) *sqlwrite.SqlWrite {
+	if len(list) == 1 && isArrayParam(list[0]) {
+		v, _ := util.Indirect(list[0])
+		sqw := &sqlwrite.SqlWrite{}
+		sqw.WriteParam("select unnest(CAST(? AS "+pgArrayType(v.Type().Elem())+")) ", sqlval.Array{V: list[0].Interface()})
+		markEmptyArray(sqw, list[0])
+		return sqw
+	}
+	return inParameter(list...)
+}
+
+// pgArrayType 切片元素类型对应的PostgreSQL数组类型, 与sqlval.Array的元素格式一致
+func pgArrayType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return "timestamptz[]"
+	}
+	switch t.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "smallint[]"
+	case reflect.Int32, reflect.Uint16:
+		return "integer[]"
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "bigint[]"
+	case reflect.Uint, reflect.Uint64:
+		return "numeric[]"
+	case reflect.Float32:
+		return "real[]"
+	case reflect.Float64:
+		return "double precision[]"
+	case reflect.Bool:
+		return "boolean[]"
+	}
+	return "text[]"
+}
+
+// markEmptyArray 空数组不会产生语法错误, 只标记以便按照EmptyIn设置处理
+func markEmptyArray(sqw *sqlwrite.SqlWrite, v reflect.Value) {
+	if v, _ = util.Indirect(v); v.Len() == 0 {
+		sqw.MarkEmptyIn()
+	}
+}
@@ -0,0 +1,107 @@
+package tgsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+type dryRunKey struct{}
+
+// NewDryRun 在上下文中开启dry-run, 只渲染sql并记录日志, 不获取数据库连接
+func NewDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+func GetDryRun(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	if dryRun, ok := ctx.Value(dryRunKey{}).(bool); ok && dryRun {
+		return true
+	}
+	return false
+}
+
+// SetDryRun 全局开启dry-run, 所有DAO方法和SqlTemplate都不会访问数据库并返回零值
+func (tdb *TgenSql) SetDryRun(dryRun bool) {
+	tdb.dryRun = dryRun
+}
+
+func (tdb *TgenSql) isDryRun(ctx context.Context) bool {
+	return tdb.dryRun || GetDryRun(ctx)
+}
+
+// Render 以dry-run方式调用DAO方法, 返回渲染的第一条sql和参数
+//
+//	sql, args, err := tdb.Render(ctx, userDB.List, 20, "张")
+//
+// args不包含context.Context参数, DAO方法必须有context.Context参数
+func (tdb *TgenSql) Render(ctx context.Context, daoFunc any, args ...any) (sql string, sqlArgs []any, err error) {
+	items, err := tdb.RenderAll(ctx, daoFunc, args...)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(items) == 0 {
+		return "", nil, errors.New("render dao func not sql statement")
+	}
+	return items[0].Sql, items[0].Args, nil
+}
+
+// RenderAll 与Render相同, 返回批量执行或分片执行渲染的所有sql
+func (tdb *TgenSql) RenderAll(ctx context.Context, daoFunc any, args ...any) (items []RecordSqlItem, err error) {
+	fv := reflect.ValueOf(daoFunc)
+	if fv.Kind() != reflect.Func || fv.IsNil() {
+		return nil, errors.New("render dao func is not a initialized func")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	recordSql := &RecordSql{}
+	ctx = NewDryRun(context.WithValue(ctx, recordSqlKey{}, recordSql))
+	ft := fv.Type()
+	in := make([]reflect.Value, ft.NumIn())
+	hasCtx := false
+	argIndex := 0
+	for i := range in {
+		it := ft.In(i)
+		if it.Implements(contextType) {
+			in[i] = reflect.ValueOf(ctx)
+			hasCtx = true
+			continue
+		}
+		if argIndex >= len(args) {
+			return nil, fmt.Errorf("render dao func in(%d) missing argument", i)
+		}
+		av := reflect.ValueOf(args[argIndex])
+		argIndex++
+		if !av.IsValid() {
+			av = reflect.Zero(it)
+		}
+		if !av.Type().AssignableTo(it) {
+			return nil, fmt.Errorf("render dao func in(%d) type %s not assignable to %s", i, av.Type(), it)
+		}
+		in[i] = av
+	}
+	if !hasCtx {
+		return nil, errors.New("render dao func must have a context.Context parameter")
+	}
+	if argIndex != len(args) {
+		return nil, fmt.Errorf("render dao func too many arguments: %d", len(args))
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			if pe, ok := e.(error); ok {
+				err = pe
+				return
+			}
+			panic(e)
+		}
+	}()
+	out := fv.Call(in)
+	if n := len(out); n > 0 && ft.Out(n-1).Implements(errorType) && !out[n-1].IsNil() {
+		return nil, out[n-1].Interface().(error)
+	}
+	return recordSql.Items(), nil
+}
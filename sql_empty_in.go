@@ -0,0 +1,79 @@
+package tgsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tianxinzizhen/tgsql/sqlwrite"
+)
+
+// EmptyIn in和any函数参数为空切片时的处理方式
+type EmptyIn int
+
+const (
+	EmptyInDefault EmptyIn = iota // 未设置, 与EmptyInNull相同
+	EmptyInNull                   // 输出in (NULL), 条件恒为假
+	EmptyInError                  // 不执行sql, 返回ErrEmptyIn
+	EmptyInSkip                   // 不执行sql, DAO方法直接返回零值
+)
+
+var ErrEmptyIn = errors.New("in sql function parameter is empty")
+
+// errSkipExec 渲染后不再执行sql
+var errSkipExec = errors.New("skip exec sql")
+
+func ParseEmptyIn(s string) (EmptyIn, error) {
+	switch s {
+	case "", "default":
+		return EmptyInDefault, nil
+	case "null":
+		return EmptyInNull, nil
+	case "error":
+		return EmptyInError, nil
+	case "skip":
+		return EmptyInSkip, nil
+	}
+	return EmptyInDefault, fmt.Errorf("empty in mode %s not support", s)
+}
+
+type emptyInKey struct{}
+
+// NewEmptyIn 在上下文中设置空切片的处理方式, 优先于?option{empty_in:...}和SetEmptyIn
+func NewEmptyIn(ctx context.Context, mode EmptyIn) context.Context {
+	return context.WithValue(ctx, emptyInKey{}, mode)
+}
+
+func GetEmptyIn(ctx context.Context) (EmptyIn, bool) {
+	if ctx == nil {
+		return EmptyInDefault, false
+	}
+	mode, ok := ctx.Value(emptyInKey{}).(EmptyIn)
+	return mode, ok
+}
+
+// SetEmptyIn 全局设置in和any函数参数为空切片时的处理方式, 单个方法可以使用?option{empty_in:skip}覆盖
+func (tdb *TgenSql) SetEmptyIn(mode EmptyIn) {
+	tdb.emptyIn = mode
+}
+
+// checkEmptyIn sql中有空切片的in时按照上下文、方法选项、全局设置的顺序决定是否继续执行
+func (tdb *TgenSql) checkEmptyIn(op *funcExecOption, sqw *sqlwrite.SqlWrite) error {
+	if !sqw.EmptyIn() {
+		return nil
+	}
+	mode := tdb.emptyIn
+	if op.emptyIn != EmptyInDefault {
+		mode = op.emptyIn
+	}
+	if m, ok := GetEmptyIn(op.ctx); ok && m != EmptyInDefault {
+		mode = m
+	}
+	switch mode {
+	case EmptyInError:
+		return ErrEmptyIn
+	case EmptyInSkip:
+		return errSkipExec
+	}
+	return nil
+}
@@ -24,8 +24,12 @@ func init() {
 	RegisterTemplateFunc("marshal", marshal)
 	RegisterTemplateFunc("json", marshal)
 	RegisterTemplateFunc("in", inParameter)
+	RegisterTemplateFunc("any", anyParameter)
 	RegisterTemplateFunc("set", setParameter)
 	RegisterTemplateFunc("where", whereParameter)
+	RegisterTemplateFunc("setnull", setNullParameter)
+	RegisterTemplateFunc("wherenull", whereNullParameter)
+	RegisterTemplateFunc("foreach", foreach)
 }
 
 func RegisterTemplateFunc(key string, funcMethod any) error {
@@ -130,6 +134,7 @@ func inParameter(list ...reflect.Value) *sqlwrite.SqlWrite {
 	sqw := &sqlwrite.SqlWrite{}
 	var num int
 	for _, v := range list {
+		v, _ = util.Indirect(v)
 		if v.Kind() == reflect.Slice {
 			for i := 0; i < v.Len(); i++ {
 				if num > 0 {
@@ -146,59 +151,127 @@ func inParameter(list ...reflect.Value) *sqlwrite.SqlWrite {
 			sqw.WriteParam("? ", v.Interface())
 		}
 	}
+	if num == 0 {
+		// 空切片输出in (NULL), 避免in ()语法错误
+		sqw.WriteString("NULL")
+		sqw.MarkEmptyIn()
+	}
 	return sqw
 }
 
-func setParameter(list ...reflect.Value) (*sqlwrite.SqlWrite, error) {
+// foreach 遍历切片, 每个元素按item输出, 元素之间用sep分隔, 首尾加上open和close.
+// item中的每个?依次绑定fields中的字段(结构体字段或map的key), 没有fields时item只能有一个?, 绑定元素本身.
+// item为空时, 一个字段输出?, 多个字段输出(?, ?). 列表为空时item中的?输出NULL, 按照EmptyIn设置处理
+func foreach(list reflect.Value, open, sep, close, item string, fields ...string) (*sqlwrite.SqlWrite, error) {
+	list, isNil := util.Indirect(list)
+	if !isNil && list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return nil, fmt.Errorf("foreach sql function in paramter is not slice")
+	}
+	if item == "" {
+		item = "?"
+		if len(fields) > 1 {
+			item = "(" + strings.TrimSuffix(strings.Repeat("?, ", len(fields)), ", ") + ")"
+		}
+	}
+	segments := strings.Split(item, "?")
+	if n := max(len(fields), 1); len(segments)-1 != n {
+		return nil, fmt.Errorf("foreach sql function item %q needs %d placeholders", item, n)
+	}
 	sqw := &sqlwrite.SqlWrite{}
-	preAlias := ""
-	var num int
-	for _, param := range list {
-		switch param.Kind() {
-		case reflect.String:
-			if preAlias == "" {
-				preAlias = param.Interface().(string) + "."
-			}
-		case reflect.Map:
-			if param.Type().Key().Kind() != reflect.String {
-				preAlias = ""
-				continue
-			}
-			iter := param.MapRange()
-			for iter.Next() {
-				name := iter.Key().Interface().(string)
-				if num > 0 {
-					sqw.WriteString(",")
-				}
-				num++
-				sqw.WriteParam(fmt.Sprintf("%s = ?", preAlias+name), iter.Value().Interface())
-			}
-			preAlias = ""
-		case reflect.Struct:
-			for i := 0; i < param.NumField(); i++ {
-				val := param.Field(i).Interface()
-				if truth, ok := template.IsTrue(val); ok && truth {
-					name := param.Type().Field(i).Name
-					if num > 0 {
-						sqw.WriteString(",")
-					}
-					num++
-					sqw.WriteParam(fmt.Sprintf("%s = ?", preAlias+name), val)
+	sqw.WriteString(open)
+	if isNil || list.Len() == 0 {
+		// item中的?都输出NULL, 多个字段时输出(NULL, NULL), 列数与元组一致
+		sqw.WriteString(strings.Join(segments, "NULL"))
+		sqw.WriteString(close)
+		sqw.MarkEmptyIn()
+		return sqw, nil
+	}
+	for i := 0; i < list.Len(); i++ {
+		if i > 0 {
+			sqw.WriteString(sep)
+		}
+		elem := list.Index(i)
+		sqw.WriteString(segments[0])
+		for j, seg := range segments[1:] {
+			if len(fields) == 0 {
+				sqw.WriteParam("?", elem.Interface())
+			} else {
+				val, err := foreachField(elem, fields[j])
+				if err != nil {
+					return nil, err
 				}
+				sqw.WriteParam("?", val)
 			}
-			preAlias = ""
-		default:
-			return nil, fmt.Errorf("setParameter sql function in paramter is not string, map or struct")
+			sqw.WriteString(seg)
 		}
 	}
+	sqw.WriteString(close)
 	return sqw, nil
 }
 
+// foreachField 按名称从结构体或map元素中取值, 结构体字段名称同时支持id_name和IdName的写法
+func foreachField(elem reflect.Value, name string) (any, error) {
+	v, isNil := util.Indirect(elem)
+	if isNil {
+		return nil, fmt.Errorf("foreach sql function element is nil")
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			if fv := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())); fv.IsValid() {
+				return fv.Interface(), nil
+			}
+			return nil, nil
+		}
+	case reflect.Struct:
+		f, ok := v.Type().FieldByName(name)
+		if !ok {
+			f, ok = v.Type().FieldByName(template.DefaultFieldName(v.Type(), name))
+		}
+		if ok && f.IsExported() {
+			return v.FieldByIndex(f.Index).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("foreach sql function element %s has no field %s", v.Type(), name)
+}
+
+func setParameter(list ...reflect.Value) (*sqlwrite.SqlWrite, error) {
+	return columnParameter("setParameter", ",", "", list)
+}
+
 func whereParameter(list ...reflect.Value) (*sqlwrite.SqlWrite, error) {
+	return columnParameter("whereParameter", " and ", "", list)
+}
+
+// setNullParameter 与set相同, 结构体中为nil的指针字段输出= NULL, 不再跳过
+func setNullParameter(list ...reflect.Value) (*sqlwrite.SqlWrite, error) {
+	return columnParameter("setNullParameter", ",", " = NULL", list)
+}
+
+// whereNullParameter 与where相同, 结构体中为nil的指针字段和map中为nil的值输出IS NULL
+func whereNullParameter(list ...reflect.Value) (*sqlwrite.SqlWrite, error) {
+	return columnParameter("whereNullParameter", " and ", " IS NULL", list)
+}
+
+// columnParameter 输出name = ?列表, 字符串参数作为之后map或结构体的表别名.
+// nullSql不为空时nil值输出name+nullSql, 否则结构体跳过无效值, map的nil值绑定NULL
+func columnParameter(funcName, sep, nullSql string, list []reflect.Value) (*sqlwrite.SqlWrite, error) {
 	sqw := &sqlwrite.SqlWrite{}
 	preAlias := ""
 	var num int
+	writeColumn := func(name string, val reflect.Value) {
+		if num > 0 {
+			sqw.WriteString(sep)
+		}
+		num++
+		if _, isNil := util.Indirect(val); isNil && nullSql != "" {
+			sqw.WriteString(preAlias + name + nullSql)
+			return
+		}
+		sqw.WriteParam(fmt.Sprintf("%s = ?", preAlias+name), val.Interface())
+	}
 	for _, param := range list {
+		param, _ = util.Indirect(param)
 		switch param.Kind() {
 		case reflect.String:
 			if preAlias == "" {
@@ -211,29 +284,20 @@ func whereParameter(list ...reflect.Value) (*sqlwrite.SqlWrite, error) {
 			}
 			iter := param.MapRange()
 			for iter.Next() {
-				name := iter.Key().Interface().(string)
-				if num > 0 {
-					sqw.WriteString(" and ")
-				}
-				num++
-				sqw.WriteParam(fmt.Sprintf("%s = ?", preAlias+name), iter.Value().Interface())
+				writeColumn(iter.Key().String(), iter.Value())
 			}
 			preAlias = ""
 		case reflect.Struct:
 			for i := 0; i < param.NumField(); i++ {
-				val := param.Field(i).Interface()
-				if truth, ok := template.IsTrue(val); ok && truth {
-					name := param.Type().Field(i).Name
-					if num > 0 {
-						sqw.WriteString(" and ")
-					}
-					num++
-					sqw.WriteParam(fmt.Sprintf("%s = ?", preAlias+name), val)
+				field := param.Field(i)
+				_, isNil := util.Indirect(field)
+				if truth, ok := template.IsTrue(field.Interface()); ok && truth || isNil && nullSql != "" && field.Kind() == reflect.Pointer {
+					writeColumn(param.Type().Field(i).Name, field)
 				}
 			}
 			preAlias = ""
 		default:
-			return nil, fmt.Errorf("setParameter sql function in paramter is not string, map or struct")
+			return nil, fmt.Errorf("%s sql function in paramter is not string, map or struct", funcName)
 		}
 	}
 	return sqw, nil
@@ -0,0 +1,114 @@
+package tgsql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/tianxinzizhen/tgsql/sqlval"
+)
+
+type StmtAction string
+
+const (
+	StmtQuery   StmtAction = "query"
+	StmtExec    StmtAction = "exec"
+	StmtPrepare StmtAction = "prepare"
+	StmtBatch   StmtAction = "batch"
+)
+
+// StmtInfo 拦截器中可见的语句信息, 在调用next之前修改Sql和Args会改变实际执行的语句.
+// 批量执行时Sql和Args为第一行渲染的语句, 只用于查看, 修改不影响实际执行的语句
+type StmtInfo struct {
+	DaoType  string
+	FuncName string
+	Action   StmtAction
+	// 批量执行中的prepare和exec, 它们的耗时和影响行数已经包含在批量执行中
+	InBatch bool
+	Sql     string
+	Args    []any
+	// 语句开始执行时间和耗时, 不包含拦截器本身的耗时
+	Start    time.Time
+	Duration time.Duration
+	// exec执行结果, 批量执行时为所有语句影响行数的和
+	Result       sql.Result
+	RowsAffected int64
+	// query返回的行数
+	Rows int64
+	// 批量执行的语句数
+	BatchRows int64
+	Err       error
+}
+
+type Next func(ctx context.Context) error
+
+type Interceptor func(ctx context.Context, info *StmtInfo, next Next) error
+
+// Use 添加拦截器, 先添加的拦截器在外层
+func (tdb *TgenSql) Use(interceptors ...Interceptor) {
+	tdb.interceptors = append(tdb.interceptors, interceptors...)
+}
+
+func (tdb *TgenSql) intercept(op *funcExecOption, action StmtAction, fn func(info *StmtInfo) error) error {
+	info := &StmtInfo{
+		DaoType:  op.daoType,
+		FuncName: op.funcName,
+		Action:   action,
+		InBatch:  op.inBatch,
+		Sql:      op.sql,
+		Args:     op.args,
+	}
+	ctx := op.ctx
+	if tdb.isDryRun(ctx) {
+		// dry-run只记录语句, 不经过拦截器
+		if action == StmtBatch {
+			return fn(info)
+		}
+		if err := tdb.convertArgs(op, info); err != nil {
+			return err
+		}
+		info.Start = time.Now()
+		tdb.logStmt(ctx, op, info)
+		tdb.recordStmt(ctx, info)
+		return nil
+	}
+	defer func() {
+		op.ctx = ctx
+	}()
+	var call func(i int, ctx context.Context) error
+	call = func(i int, ctx context.Context) error {
+		if i < len(tdb.interceptors) {
+			return tdb.interceptors[i](ctx, info, func(ctx context.Context) error {
+				return call(i+1, ctx)
+			})
+		}
+		op.ctx = ctx
+		op.sql, op.args = info.Sql, info.Args
+		if err := tdb.convertArgs(op, info); err != nil {
+			return err
+		}
+		info.Start = time.Now()
+		info.Err = fn(info)
+		info.Duration = time.Since(info.Start)
+		return info.Err
+	}
+	err := call(0, ctx)
+	if !info.Start.IsZero() {
+		tdb.logStmt(ctx, op, info)
+		tdb.recordStmt(ctx, info)
+	}
+	return err
+}
+
+// convertArgs 在拦截器之后转换query和exec的参数, 拦截器改写的参数同样会被转换
+func (tdb *TgenSql) convertArgs(op *funcExecOption, info *StmtInfo) (err error) {
+	if info.Action != StmtQuery && info.Action != StmtExec {
+		return nil
+	}
+	op.args, err = sqlval.ConvertValues(op.db, op.args)
+	if err != nil {
+		return err
+	}
+	info.Args = op.args
+	return nil
+}
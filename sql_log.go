@@ -0,0 +1,99 @@
+package tgsql
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SqlLogger 语句执行完成后的日志, Slow在执行时间超过慢查询阈值时额外调用, sql为参数插值后的语句
+type SqlLogger interface {
+	Log(ctx context.Context, info *StmtInfo)
+	Slow(ctx context.Context, info *StmtInfo, sql string)
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger 基于log/slog的SqlLogger, 语句日志使用Debug级别, 慢查询使用Warn级别
+func NewSlogLogger(logger *slog.Logger) SqlLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) attrs(info *StmtInfo) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("dao", info.DaoType),
+		slog.String("func", info.FuncName),
+		slog.String("action", string(info.Action)),
+		slog.String("sql", info.Sql),
+		slog.Any("args", info.Args),
+		slog.Time("start", info.Start),
+		slog.Duration("duration", info.Duration),
+		slog.Int64("rows", info.Rows),
+		slog.Int64("rows_affected", info.RowsAffected),
+	}
+	if info.Err != nil {
+		attrs = append(attrs, slog.Any("error", info.Err))
+	}
+	return attrs
+}
+
+func (l *slogLogger) Log(ctx context.Context, info *StmtInfo) {
+	level := slog.LevelDebug
+	if info.Err != nil {
+		level = slog.LevelError
+	}
+	l.logger.LogAttrs(ctx, level, "tgsql statement", l.attrs(info)...)
+}
+
+func (l *slogLogger) Slow(ctx context.Context, info *StmtInfo, sql string) {
+	l.logger.LogAttrs(ctx, slog.LevelWarn, "tgsql slow statement", append(l.attrs(info), slog.String("interpolated_sql", sql))...)
+}
+
+func (tdb *TgenSql) SetSqlLogger(logger SqlLogger) {
+	tdb.sqlLogger = logger
+}
+
+// SetSlowThreshold 设置慢查询阈值, 没有设置SqlLogger时使用slog.Default()输出慢查询
+func (tdb *TgenSql) SetSlowThreshold(threshold time.Duration) {
+	tdb.slowThreshold = threshold
+}
+
+// logStmt 只记录query、exec和批量执行, 批量执行中的prepare和每一行的exec不单独记录
+func (tdb *TgenSql) logStmt(ctx context.Context, op *funcExecOption, info *StmtInfo) {
+	if info.Action == StmtPrepare || info.Action == StmtExec && op.stmt != nil {
+		return
+	}
+	logger := tdb.sqlLogger
+	if logger == nil {
+		if tdb.slowThreshold <= 0 {
+			return
+		}
+		logger = NewSlogLogger(nil)
+	} else {
+		logger.Log(ctx, info)
+	}
+	if tdb.slowThreshold > 0 && info.Duration >= tdb.slowThreshold {
+		logger.Slow(ctx, info, tdb.slowSql(op, info))
+	}
+}
+
+// slowSql 按渲染时参数占位符的位置插值, 避免替换字符串中的?和已经改写为$n的占位符,
+// 拦截器改写了sql时不插值
+func (tdb *TgenSql) slowSql(op *funcExecOption, info *StmtInfo) string {
+	if len(info.Args) == 0 || op.sqlWrite == nil || info.Sql != op.renderSql {
+		return info.Sql
+	}
+	sql, err := tdb.interpolate(op.sqlWrite, info.Args)
+	if err != nil {
+		return info.Sql
+	}
+	if op.shardTable != "" {
+		sql = rewriteShardTable(sql, op.shardTable, op.shardSuffix)
+	}
+	return sql
+}
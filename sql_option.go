@@ -4,18 +4,33 @@ import (
 	"context"
 	"database/sql"
 	"reflect"
+
+	"github.com/tianxinzizhen/tgsql/sqlval"
+	"github.com/tianxinzizhen/tgsql/sqlwrite"
 )
 
 type funcExecOption struct {
-	ctx    context.Context
-	param  any
-	result []reflect.Value
-	sql    string
-	args   []any
-	option int
-	offset int
-	db     any
-	stmt   *sql.Stmt
+	daoType  string
+	funcName string
+	ctx      context.Context
+	param    any
+	result   []reflect.Value
+	sql      string
+	args     []any
+	option   int
+	offset   int
+	db       any
+	stmt     *sql.Stmt
+	ret      sql.Result
+	scanMode sqlval.ScanMode
+	emptyIn  EmptyIn
+	// 分表后缀
+	shardTable  string
+	shardSuffix string
+	// 渲染结果和渲染后的sql, 慢查询日志按渲染时参数占位符的位置插值
+	sqlWrite  *sqlwrite.SqlWrite
+	renderSql string
+	inBatch   bool
 }
 
 func (op *funcExecOption) GetDB(ctx context.Context) any {
@@ -1,14 +1,40 @@
 package tgsql
 
-import "context"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
 type recordSqlKey struct{}
 type RecordSqlItem struct {
-	Sql  string
-	Args []any
+	DaoType      string
+	FuncName     string
+	Sql          string
+	Args         []any
+	Start        time.Time
+	Duration     time.Duration
+	Rows         int64
+	RowsAffected int64
+	Err          error
 }
+
+// RecordSql 记录上下文中执行过的sql, 通过Items获取记录的副本
 type RecordSql struct {
-	List []RecordSqlItem
+	mu   sync.Mutex
+	list []RecordSqlItem
+}
+
+func (rs *RecordSql) add(item RecordSqlItem) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.list = append(rs.list, item)
+}
+
+func (rs *RecordSql) Items() []RecordSqlItem {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return append([]RecordSqlItem(nil), rs.list...)
 }
 
 func (tdb *TgenSql) FromRecordSql(ctx context.Context) (*RecordSql, bool) {
@@ -25,3 +51,24 @@ func (tdb *TgenSql) NewRecordSql(ctx context.Context) context.Context {
 	}
 	return context.WithValue(ctx, recordSqlKey{}, &RecordSql{})
 }
+
+func (tdb *TgenSql) recordStmt(ctx context.Context, info *StmtInfo) {
+	switch info.Action {
+	case StmtQuery, StmtExec:
+	default:
+		return
+	}
+	if recordSql, ok := tdb.FromRecordSql(ctx); ok {
+		recordSql.add(RecordSqlItem{
+			DaoType:      info.DaoType,
+			FuncName:     info.FuncName,
+			Sql:          info.Sql,
+			Args:         info.Args,
+			Start:        info.Start,
+			Duration:     info.Duration,
+			Rows:         info.Rows,
+			RowsAffected: info.RowsAffected,
+			Err:          info.Err,
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package tgsql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+
+	"github.com/tianxinzizhen/tgsql/load"
+	"github.com/tianxinzizhen/tgsql/util"
+)
+
+var ErrShardKeyMissing = errors.New("shard key param is missing")
+
+// ShardRule 分库分表规则, 通过?option{shard:name}在方法上启用
+type ShardRule struct {
+	// 分片键参数名称, 例如user_id
+	Param string
+	// 分片数据源名称, 需要先通过AddDB注册
+	Shards []string
+	// 需要添加分表后缀的逻辑表名, 为空时不改写sql
+	Table string
+	// 分表后缀格式, 默认为"_%02d"
+	Suffix string
+	// 根据分片键计算分片下标, 默认为HashShard
+	Route func(key any, n int) (int, error)
+	// 没有分片键的查询是否在所有分片上执行并合并结果, 否则直接返回错误
+	FanOut bool
+}
+
+type shardTarget struct {
+	dataSource string
+	table      string
+	suffix     string
+	// 批量插入时分到这个分片的行
+	param any
+	shard bool
+}
+
+// dataSourceContext 分片的数据源优先于调用上下文中指定的数据源
+func (t shardTarget) dataSourceContext(ctx context.Context) context.Context {
+	if t.shard {
+		return NewDataSource(ctx, t.dataSource)
+	}
+	return ctx
+}
+
+func (tdb *TgenSql) AddShardRule(name string, rule *ShardRule) error {
+	if rule == nil || len(rule.Shards) == 0 {
+		return fmt.Errorf("shard rule[%s] has no shards", name)
+	}
+	if rule.Param == "" {
+		return fmt.Errorf("shard rule[%s] param is empty", name)
+	}
+	if tdb.shardRules == nil {
+		tdb.shardRules = make(map[string]*ShardRule)
+	}
+	tdb.shardRules[name] = rule
+	return nil
+}
+
+func (r *ShardRule) target(i int) shardTarget {
+	target := shardTarget{dataSource: r.Shards[i], table: r.Table, shard: true}
+	if r.Table != "" {
+		suffix := r.Suffix
+		if suffix == "" {
+			suffix = "_%02d"
+		}
+		target.suffix = fmt.Sprintf(suffix, i)
+	}
+	return target
+}
+
+func (tdb *TgenSql) shardTargets(name string, action Operation, param any, dataSource string) ([]shardTarget, error) {
+	if name == "" {
+		return []shardTarget{{dataSource: dataSource}}, nil
+	}
+	rule, ok := tdb.shardRules[name]
+	if !ok {
+		return nil, fmt.Errorf("shard rule[%s] not registered", name)
+	}
+	if key, ok := paramValue(tdb.filedName, param, rule.Param); ok {
+		route := rule.Route
+		if route == nil {
+			route = HashShard
+		}
+		i, err := route(key, len(rule.Shards))
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= len(rule.Shards) {
+			return nil, fmt.Errorf("shard rule[%s] route index %d out of range", name, i)
+		}
+		return []shardTarget{rule.target(i)}, nil
+	}
+	if !rule.FanOut || action != selectAction {
+		return nil, fmt.Errorf("shard rule[%s] %w: %s", name, ErrShardKeyMissing, rule.Param)
+	}
+	targets := make([]shardTarget, len(rule.Shards))
+	for i := range rule.Shards {
+		targets[i] = rule.target(i)
+	}
+	return targets, nil
+}
+
+// shardFanOutOption 在所有分片上执行时每个分片的结果追加到同一个切片中, 多个返回值时会互相覆盖, 只支持一个切片和error
+func (tdb *TgenSql) shardFanOutOption(fct reflect.Type, sqlInfo *load.SqlDataInfo) error {
+	if rule, ok := tdb.shardRules[sqlInfo.Shard]; !ok || !rule.FanOut {
+		return nil
+	}
+	return fanOutResult(fct, sqlInfo.Shard)
+}
+
+func fanOutResult(fct reflect.Type, name string) error {
+	for i := 1; i < fct.NumOut(); i++ {
+		if !fct.Out(i).Implements(errorType) {
+			return fmt.Errorf("shard rule[%s] fan out only support select func returning one slice and error", name)
+		}
+	}
+	return nil
+}
+
+// shardBatchTargets 批量插入按每行的分片键分组, 每组在对应的分片上执行
+func (tdb *TgenSql) shardBatchTargets(name string, param any, dataSource string) ([]shardTarget, error) {
+	pv := reflect.ValueOf(param)
+	if name == "" || pv.Kind() != reflect.Slice || pv.Len() == 0 {
+		return []shardTarget{{dataSource: dataSource}}, nil
+	}
+	var targets []shardTarget
+	rows := map[shardTarget]reflect.Value{}
+	for i := 0; i < pv.Len(); i++ {
+		ts, err := tdb.shardTargets(name, execNoResultAction, pv.Index(i).Interface(), dataSource)
+		if err != nil {
+			return nil, err
+		}
+		target := ts[0]
+		if _, ok := rows[target]; !ok {
+			targets = append(targets, target)
+			rows[target] = reflect.MakeSlice(pv.Type(), 0, pv.Len())
+		}
+		rows[target] = reflect.Append(rows[target], pv.Index(i))
+	}
+	for i, target := range targets {
+		targets[i].param = rows[target].Interface()
+	}
+	return targets, nil
+}
+
+// paramValue 按参数名称从map或结构体中取值
+func paramValue(filedName func(t reflect.Type, name string) string, param any, name string) (any, bool) {
+	pv, isNil := util.Indirect(reflect.ValueOf(param))
+	if isNil || !pv.IsValid() {
+		return nil, false
+	}
+	switch pv.Kind() {
+	case reflect.Map:
+		if pv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		v := pv.MapIndex(reflect.ValueOf(name).Convert(pv.Type().Key()))
+		if !v.IsValid() {
+			return nil, false
+		}
+		return v.Interface(), true
+	case reflect.Struct:
+		v := pv.FieldByName(filedName(pv.Type(), name))
+		if !v.IsValid() || !v.CanInterface() {
+			return nil, false
+		}
+		return v.Interface(), true
+	}
+	return nil, false
+}
+
+// HashShard 整数分片键取模, 其他类型取fnv哈希后取模
+func HashShard(key any, n int) (int, error) {
+	kv, isNil := util.Indirect(reflect.ValueOf(key))
+	if isNil || !kv.IsValid() {
+		return 0, ErrShardKeyMissing
+	}
+	switch kv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := kv.Int() % int64(n)
+		if i < 0 {
+			i = -i
+		}
+		return int(i), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(kv.Uint() % uint64(n)), nil
+	}
+	h := fnv.New32a()
+	h.Write([]byte(fmt.Sprint(kv.Interface())))
+	return int(h.Sum32() % uint32(n)), nil
+}
+
+// RangeShard 按范围分片, 分片键小于bounds[i]时落在第i个分片, 大于等于最后一个边界时落在最后一个分片
+func RangeShard(bounds ...int64) func(key any, n int) (int, error) {
+	return func(key any, n int) (int, error) {
+		kv, isNil := util.Indirect(reflect.ValueOf(key))
+		if isNil || !kv.IsValid() {
+			return 0, ErrShardKeyMissing
+		}
+		var k int64
+		switch kv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			k = kv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			k = int64(kv.Uint())
+		default:
+			return 0, fmt.Errorf("range shard key type not support %s", kv.Type())
+		}
+		for i, b := range bounds {
+			if k < b {
+				return i, nil
+			}
+		}
+		return n - 1, nil
+	}
+}
+
+// rewriteShardTable 将from、join、update、into、table之后的表名改写为table+suffix,
+// 字符串、注释和table.column形式的列名不改写, 表名需要带后缀的列使用表别名引用
+func rewriteShardTable(sql, table, suffix string) string {
+	sb := strings.Builder{}
+	prevWord := ""
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := quoteEnd(sql, i)
+			sb.WriteString(sql[i:end])
+			i = end
+			prevWord = ""
+		case c == '`':
+			end := quoteEnd(sql, i)
+			name := strings.Trim(sql[i:end], "`")
+			if name == table && isTableKeyword(prevWord) && !strings.HasPrefix(sql[end:], ".") {
+				sb.WriteString("`" + table + suffix + "`")
+			} else {
+				sb.WriteString(sql[i:end])
+			}
+			i = end
+			prevWord = ""
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			sb.WriteString(sql[i : i+end])
+			i += end
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i
+			} else {
+				end += 4
+			}
+			sb.WriteString(sql[i : i+end])
+			i += end
+		case isIdentByte(c):
+			end := i
+			for end < len(sql) && isIdentByte(sql[end]) {
+				end++
+			}
+			word := sql[i:end]
+			qualified := i > 0 && sql[i-1] == '.' || strings.HasPrefix(sql[end:], ".")
+			if word == table && isTableKeyword(prevWord) && !qualified {
+				sb.WriteString(table + suffix)
+			} else {
+				sb.WriteString(word)
+			}
+			prevWord = word
+			i = end
+		default:
+			sb.WriteByte(c)
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				prevWord = ""
+			}
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// quoteEnd 返回从start开始的引号字符串结束后的位置, 两个连续的引号和反斜杠转义不结束字符串
+func quoteEnd(sql string, start int) int {
+	q := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if q != '`' {
+				i++
+			}
+		case q:
+			if i+1 < len(sql) && sql[i+1] == q {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+func isTableKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "from", "join", "update", "into", "table":
+		return true
+	}
+	return false
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,273 @@
+package tgsql
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tianxinzizhen/tgsql/load"
+	"github.com/tianxinzizhen/tgsql/template"
+	"github.com/tianxinzizhen/tgsql/template/parse"
+)
+
+// SetStrict 开启严格模式, InitDBFunc时根据方法参数类型检查sql模板中引用的字段,
+// 单个方法也可以使用?option{strict:true}开启
+func (tdb *TgenSql) SetStrict(strict bool) {
+	tdb.strict = strict
+}
+
+// strictType 模板中dot的静态类型, t和keys都为空时表示类型未知不再检查
+type strictType struct {
+	t    reflect.Type
+	keys map[string]reflect.Type // 多个参数时组成的参数map
+}
+
+type strictChecker struct {
+	tdb  *TgenSql
+	tree *parse.Tree
+	root strictType
+	vars map[string]strictType
+}
+
+// paramType 与handleParam一致推导模板的参数类型
+func paramType(fct reflect.Type, sqlInfo *load.SqlDataInfo) strictType {
+	var param reflect.Type
+	var useMultiParam bool
+	for i := 0; i < fct.NumIn(); i++ {
+		it := fct.In(i)
+		if it.Implements(contextType) {
+			continue
+		}
+		pvt := it
+		if pvt.Kind() == reflect.Pointer {
+			pvt = pvt.Elem()
+		}
+		switch pvt.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Struct:
+			if param == nil {
+				param = it
+			} else {
+				useMultiParam = true
+			}
+		default:
+			useMultiParam = true
+		}
+	}
+	if useMultiParam && len(sqlInfo.Param) > 0 {
+		keys := map[string]reflect.Type{}
+		for i, name := range sqlInfo.Param {
+			if i >= fct.NumIn() || fct.In(i).Implements(contextType) {
+				continue
+			}
+			keys[name] = fct.In(i)
+		}
+		return strictType{keys: keys}
+	}
+	return strictType{t: param}
+}
+
+// checkTemplate 检查模板中的字段引用都能在参数类型中找到
+func (tdb *TgenSql) checkTemplate(dt reflect.Type, fct reflect.Type, sqlInfo *load.SqlDataInfo, t *template.Template) error {
+	if t == nil || t.Tree == nil {
+		return nil
+	}
+	c := &strictChecker{
+		tdb:  tdb,
+		tree: t.Tree,
+		root: paramType(fct, sqlInfo),
+		vars: map[string]strictType{},
+	}
+	// 批量执行时模板参数是切片中的每一行
+	if sqlInfo.BatchInsert {
+		c.root = elemType(c.root)
+	}
+	err := c.walk(c.root, t.Tree.Root)
+	if err != nil {
+		return fmt.Errorf("NewDBFunc %s.%s strict check %w", dt.Name(), sqlInfo.Name, err)
+	}
+	return nil
+}
+
+func (c *strictChecker) errorf(n parse.Node, format string, args ...any) error {
+	location, _ := c.tree.ErrorContext(n)
+	return fmt.Errorf("%s: %s", location, fmt.Sprintf(format, args...))
+}
+
+func (c *strictChecker) walk(dot strictType, node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, v := range n.Nodes {
+			if err := c.walk(dot, v); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		_, err := c.pipe(dot, n.Pipe)
+		return err
+	case *parse.IfNode:
+		return c.branch(dot, &n.BranchNode, false, false)
+	case *parse.WithNode:
+		return c.branch(dot, &n.BranchNode, true, false)
+	case *parse.RangeNode:
+		return c.branch(dot, &n.BranchNode, true, true)
+	case *parse.ClauseNode:
+		return c.walk(dot, n.List)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			_, err := c.pipe(dot, n.Pipe)
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *strictChecker) branch(dot strictType, n *parse.BranchNode, setDot, isRange bool) error {
+	pt, err := c.pipe(dot, n.Pipe)
+	if err != nil {
+		return err
+	}
+	listDot := dot
+	if setDot {
+		listDot = pt
+		if isRange {
+			listDot = elemType(pt)
+			for _, v := range n.Pipe.Decl {
+				c.vars[v.Ident[0]] = strictType{}
+			}
+			if len(n.Pipe.Decl) > 0 {
+				c.vars[n.Pipe.Decl[len(n.Pipe.Decl)-1].Ident[0]] = listDot
+			}
+		}
+	}
+	if err := c.walk(listDot, n.List); err != nil {
+		return err
+	}
+	return c.walk(dot, n.ElseList)
+}
+
+func (c *strictChecker) pipe(dot strictType, pipe *parse.PipeNode) (strictType, error) {
+	if pipe == nil {
+		return strictType{}, nil
+	}
+	var last strictType
+	for _, cmd := range pipe.Cmds {
+		var err error
+		last = strictType{}
+		for i, arg := range cmd.Args {
+			var at strictType
+			if at, err = c.arg(dot, arg); err != nil {
+				return strictType{}, err
+			}
+			if i == 0 && len(cmd.Args) == 1 {
+				last = at
+			}
+		}
+	}
+	for _, v := range pipe.Decl {
+		c.vars[v.Ident[0]] = last
+	}
+	return last, nil
+}
+
+func (c *strictChecker) arg(dot strictType, arg parse.Node) (strictType, error) {
+	switch n := arg.(type) {
+	case *parse.DotNode:
+		return dot, nil
+	case *parse.FieldNode:
+		return c.fields(dot, n, n.Ident)
+	case *parse.VariableNode:
+		vt := c.root
+		if n.Ident[0] != "$" {
+			vt = c.vars[n.Ident[0]]
+		}
+		return c.fields(vt, n, n.Ident[1:])
+	case *parse.PipeNode:
+		return c.pipe(dot, n)
+	case *parse.ChainNode:
+		if _, err := c.arg(dot, n.Node); err != nil {
+			return strictType{}, err
+		}
+	}
+	return strictType{}, nil
+}
+
+func (c *strictChecker) fields(st strictType, n parse.Node, idents []string) (strictType, error) {
+	for _, name := range idents {
+		var err error
+		st, err = c.field(st, name)
+		if err != nil {
+			return strictType{}, c.errorf(n, "%s", err)
+		}
+	}
+	return st, nil
+}
+
+// field 与模板执行时evalField的查找顺序一致: 方法, 结构体字段, map键
+func (c *strictChecker) field(st strictType, name string) (strictType, error) {
+	if st.keys != nil {
+		if ft, ok := st.keys[name]; ok {
+			return strictType{t: ft}, nil
+		}
+		if ft, ok := st.keys[c.tdb.filedName(reflect.TypeFor[map[string]any](), name)]; ok {
+			return strictType{t: ft}, nil
+		}
+		keys := make([]string, 0, len(st.keys))
+		for k := range st.keys {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return strictType{}, fmt.Errorf("param has no field %s, params: %s", name, strings.Join(keys, ", "))
+	}
+	t := st.t
+	if t == nil {
+		return strictType{}, nil
+	}
+	if t.Kind() == reflect.Interface {
+		return strictType{}, nil
+	}
+	pt := t
+	if pt.Kind() != reflect.Pointer {
+		pt = reflect.PointerTo(t)
+	}
+	if m, ok := pt.MethodByName(name); ok {
+		if m.Type.NumOut() > 0 {
+			return strictType{t: m.Type.Out(0)}, nil
+		}
+		return strictType{}, nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if f, ok := t.FieldByName(c.tdb.filedName(t, name)); ok && f.IsExported() {
+			return strictType{t: f.Type}, nil
+		}
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return strictType{t: t.Elem()}, nil
+		}
+	case reflect.Interface:
+		return strictType{}, nil
+	}
+	return strictType{}, fmt.Errorf("can't evaluate field %s in type %s", name, st.t)
+}
+
+func elemType(st strictType) strictType {
+	t := st.t
+	if t == nil {
+		return strictType{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		return strictType{t: t.Elem()}
+	}
+	return strictType{}
+}
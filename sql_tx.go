@@ -8,6 +8,7 @@ import (
 
 type enableSqlTxKey struct{}
 type sqlTxKey struct{}
+type sqlTxDBKey struct{}
 
 func NewSqlTx(ctx context.Context, tx *sql.Tx) context.Context {
 	ctx = context.WithValue(ctx, enableSqlTxKey{}, true)
@@ -37,10 +38,16 @@ func (tdb *TgenSql) BeginTx(ctx context.Context, opts *sql.TxOptions) (context.C
 	if tx, ok := FromSqlTx(ctx); ok && tx != nil {
 		return ctx, nil
 	}
-	tx, err := tdb.db.BeginTx(ctx, opts)
+	sqlDB, err := tdb.selectDB(ctx, "")
 	if err != nil {
 		return nil, err
 	}
+	tx, err := sqlDB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	// 记录事务的数据源, 事务中调用其他数据源的方法时返回ErrTxDataSource
+	ctx = context.WithValue(ctx, sqlTxDBKey{}, sqlDB)
 	return NewSqlTx(ctx, tx), nil
 }
 func (tdb *TgenSql) AutoCommit(ctx context.Context, err *error) {
@@ -0,0 +1,219 @@
+package tgsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/tianxinzizhen/tgsql/load"
+	"github.com/tianxinzizhen/tgsql/sqlval"
+)
+
+// VerifyColumns 启动自检, 使用参数零值渲染DAO中每一个查询方法的sql, 追加LIMIT 0执行获取列信息,
+// 检查没有对应字段的列、没有对应列的必填字段(`db:",required"`)和类型不兼容的字段
+func (tdb *TgenSql) VerifyColumns(ctx context.Context, daos ...any) error {
+	var errs []error
+	for _, dao := range daos {
+		dv := reflect.ValueOf(dao)
+		for dv.Kind() == reflect.Pointer {
+			dv = dv.Elem()
+		}
+		if dv.Kind() != reflect.Struct {
+			return fmt.Errorf("VerifyColumns dao %T is not a struct", dao)
+		}
+		dt := dv.Type()
+		structDB := structDataSource(dt)
+		sqlInfos := tdb.localFuncDataInfo.GetSqlDataInfo(fmt.Sprintf("%s.%s", dt.PkgPath(), dt.Name()))
+		for _, sqlInfo := range sqlInfos {
+			fc, ok := dt.FieldByName(sqlInfo.Name)
+			if !ok || fc.Type.Kind() != reflect.Func || !isSelectFunc(fc.Type) {
+				continue
+			}
+			fv := dv.FieldByIndex(fc.Index)
+			if fv.IsNil() {
+				errs = append(errs, fmt.Errorf("%s.%s not initialized", dt.Name(), sqlInfo.Name))
+				continue
+			}
+			dataSource := structDB
+			if sqlInfo.DB != "" {
+				dataSource = sqlInfo.DB
+			}
+			for _, err := range tdb.verifyFunc(ctx, fv, sqlInfo, dataSource) {
+				errs = append(errs, fmt.Errorf("%s.%s %w", dt.Name(), sqlInfo.Name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	timeType    = reflect.TypeFor[time.Time]()
+	scannerType = reflect.TypeFor[sql.Scanner]()
+)
+
+func isSelectFunc(fct reflect.Type) bool {
+	if fct.NumOut() == 0 {
+		return false
+	}
+	out := fct.Out(0)
+	return out != sqlResultType && !out.Implements(errorType)
+}
+
+func (tdb *TgenSql) verifyFunc(ctx context.Context, fv reflect.Value, sqlInfo *load.SqlDataInfo, dataSource string) []error {
+	fct := fv.Type()
+	var args []any
+	in := make([]reflect.Value, fct.NumIn())
+	for i := 0; i < fct.NumIn(); i++ {
+		it := fct.In(i)
+		if it.Implements(contextType) {
+			in[i] = reflect.ValueOf(&ctx).Elem()
+			continue
+		}
+		if it.Kind() == reflect.Pointer {
+			in[i] = reflect.New(it.Elem())
+		} else {
+			in[i] = reflect.Zero(it)
+		}
+		args = append(args, in[i].Interface())
+	}
+	query, queryArgs, err := tdb.Render(ctx, fv.Interface(), args...)
+	if err != nil {
+		return []error{err}
+	}
+	// 分片方法在渲染时使用的第一个分片上执行
+	op := &funcExecOption{}
+	handleParam(sqlInfo, op, in)
+	targets, err := tdb.shardTargets(sqlInfo.Shard, selectAction, op.param, dataSource)
+	if err != nil {
+		return []error{err}
+	}
+	db, err := tdb.selectDB(targets[0].dataSourceContext(ctx), targets[0].dataSource)
+	if err != nil {
+		return []error{err}
+	}
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if hasTopLevelWord(query, "limit", "for") {
+		// 已经有LIMIT或者FOR UPDATE时只能包装成子查询, join中重复的列名在MySQL中会报错
+		query = fmt.Sprintf("SELECT * FROM (%s) tgsql_verify LIMIT 0", query)
+	} else {
+		// 换行避免被结尾的--注释注释掉
+		query += "\nLIMIT 0"
+	}
+	rows, err := db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return []error{err}
+	}
+	defer rows.Close()
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return []error{err}
+	}
+	var outs []reflect.Type
+	for i := 0; i < fct.NumOut(); i++ {
+		if !fct.Out(i).Implements(errorType) {
+			outs = append(outs, fct.Out(i))
+		}
+	}
+	return verifyColumns(tdb.filedName, columns, outs)
+}
+
+// hasTopLevelWord sql中括号、字符串和注释之外是否有words之一, 不区分大小写
+func hasTopLevelWord(sql string, words ...string) bool {
+	depth := 0
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = quoteEnd(sql, i)
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return false
+			}
+			i += end
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 4
+		case isIdentByte(c):
+			end := i
+			for end < len(sql) && isIdentByte(sql[end]) {
+				end++
+			}
+			if depth == 0 {
+				for _, word := range words {
+					if strings.EqualFold(sql[i:end], word) {
+						return true
+					}
+				}
+			}
+			i = end
+		default:
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+			}
+			i++
+		}
+	}
+	return false
+}
+
+// verifyColumns 与sqlval.GetScanDest的字段对应规则一致
+func verifyColumns(filedName func(t reflect.Type, name string) string, columns []*sql.ColumnType, outs []reflect.Type) (errs []error) {
+	if len(outs) > 1 {
+		for i, c := range columns {
+			if i >= len(outs) {
+				errs = append(errs, fmt.Errorf("column %s has no destination", c.Name()))
+			} else if !sqlval.ScanCompatible(c, outs[i]) {
+				errs = append(errs, fmt.Errorf("column %s %s can't scan into %s", c.Name(), c.DatabaseTypeName(), outs[i]))
+			}
+		}
+		return errs
+	}
+	t := outs[0]
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		t = t.Elem()
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Map || len(columns) == 0 || sqlval.IsScanJson(columns[0]) {
+		return nil
+	}
+	if t.Kind() != reflect.Struct || t == timeType || sqlval.IsScanVal(t) || reflect.PointerTo(t).Implements(scannerType) {
+		for i, c := range columns {
+			if i > 0 {
+				errs = append(errs, fmt.Errorf("column %s has no destination", c.Name()))
+			} else if !sqlval.ScanCompatible(c, t) {
+				errs = append(errs, fmt.Errorf("column %s %s can't scan into %s", c.Name(), c.DatabaseTypeName(), t))
+			}
+		}
+		return errs
+	}
+	mapped := map[string]bool{}
+	for _, c := range columns {
+		f, ok := t.FieldByName(filedName(t, c.Name()))
+		if !ok || !f.IsExported() {
+			errs = append(errs, fmt.Errorf("column %s has no destination field in %s", c.Name(), t))
+			continue
+		}
+		mapped[f.Name] = true
+		if !sqlval.HasTagOption(f, "json") && !sqlval.ScanCompatible(c, f.Type) {
+			errs = append(errs, fmt.Errorf("column %s %s can't scan into %s.%s %s", c.Name(), c.DatabaseTypeName(), t, f.Name, f.Type))
+		}
+	}
+	for _, f := range reflect.VisibleFields(t) {
+		if f.IsExported() && !mapped[f.Name] && sqlval.HasTagOption(f, "required") {
+			errs = append(errs, fmt.Errorf("required field %s.%s has no column", t, f.Name))
+		}
+	}
+	return errs
+}
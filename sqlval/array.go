@@ -0,0 +1,238 @@
+package sqlval
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Array 将切片作为一个PostgreSQL数组参数绑定, 以数组文本格式{1,2,3}传给驱动
+type Array struct {
+	V any
+}
+
+func (a Array) Value() (driver.Value, error) {
+	v := reflect.ValueOf(a.V)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("array param type %s not support", v.Type())
+	}
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return nil, nil
+	}
+	sb := strings.Builder{}
+	sb.WriteByte('{')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		if err := writeArrayElem(&sb, v.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+	sb.WriteByte('}')
+	return sb.String(), nil
+}
+
+var arrayQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func writeArrayElem(sb *strings.Builder, v reflect.Value) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			sb.WriteString("NULL")
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sb.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		sb.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		sb.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Bool:
+		sb.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.String:
+		sb.WriteByte('"')
+		sb.WriteString(arrayQuoter.Replace(v.String()))
+		sb.WriteByte('"')
+	default:
+		if t, ok := v.Interface().(time.Time); ok {
+			sb.WriteByte('"')
+			sb.WriteString(t.Format(time.RFC3339Nano))
+			sb.WriteByte('"')
+			return nil
+		}
+		return fmt.Errorf("array param element type %s not support", v.Type())
+	}
+	return nil
+}
+
+// isArrayColumn 判断列是PostgreSQL数组类型, 驱动返回的类型名称以_开头(_INT8、_TEXT)或者以[]结尾
+func isArrayColumn(c *sql.ColumnType) bool {
+	name := c.DatabaseTypeName()
+	return strings.HasPrefix(name, "_") || strings.HasSuffix(name, "[]")
+}
+
+// isArrayDestType 支持扫描数组的切片类型, 元素为数字、字符串、布尔或者它们的指针
+func isArrayDestType(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice || t == bytesType {
+		return false
+	}
+	et := t.Elem()
+	if et.Kind() == reflect.Pointer {
+		et = et.Elem()
+	}
+	switch et.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool, reflect.String:
+		return true
+	}
+	return false
+}
+
+func useScanValArray(c *sql.ColumnType, t reflect.Type) bool {
+	return isArrayColumn(c) && isArrayDestType(t)
+}
+
+// ScanValArray 将PostgreSQL一维数组文本解析到切片
+type ScanValArray struct {
+	Val reflect.Value
+}
+
+func newScanValArray(val reflect.Value) reflect.Value {
+	return reflect.ValueOf(&ScanValArray{Val: val})
+}
+
+func (s *ScanValArray) Scan(src any) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		s.Val.SetZero()
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("scan array from %T not support", src)
+	}
+	elems, err := parseArray(text)
+	if err != nil {
+		return err
+	}
+	t := s.Val.Type()
+	slice := reflect.MakeSlice(t, len(elems), len(elems))
+	for i, e := range elems {
+		ev := slice.Index(i)
+		if e == nil {
+			if ev.Kind() != reflect.Pointer {
+				return fmt.Errorf("array element %d is NULL, can't scan into %s", i, t.Elem())
+			}
+			continue
+		}
+		if ev.Kind() == reflect.Pointer {
+			ev.Set(reflect.New(ev.Type().Elem()))
+			ev = ev.Elem()
+		}
+		if err := setArrayElem(ev, *e); err != nil {
+			return fmt.Errorf("array element %d: %w", i, err)
+		}
+	}
+	s.Val.Set(slice)
+	return nil
+}
+
+func setArrayElem(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		switch s {
+		case "t", "true", "TRUE":
+			v.SetBool(true)
+		case "f", "false", "FALSE":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("invalid bool %q", s)
+		}
+	}
+	return nil
+}
+
+// parseArray 解析一维数组文本, NULL元素返回nil
+func parseArray(text string) ([]*string, error) {
+	if len(text) < 2 || text[0] != '{' || text[len(text)-1] != '}' {
+		return nil, fmt.Errorf("invalid array %q", text)
+	}
+	body := text[1 : len(text)-1]
+	var elems []*string
+	if body == "" {
+		return elems, nil
+	}
+	for i := 0; i <= len(body); {
+		if i < len(body) && body[i] == '{' {
+			return nil, fmt.Errorf("multidimensional array %q not support", text)
+		}
+		var elem strings.Builder
+		quoted := false
+		if i < len(body) && body[i] == '"' {
+			quoted = true
+			i++
+			for ; i < len(body) && body[i] != '"'; i++ {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				elem.WriteByte(body[i])
+			}
+			if i >= len(body) {
+				return nil, fmt.Errorf("invalid array %q", text)
+			}
+			i++
+		} else {
+			for ; i < len(body) && body[i] != ','; i++ {
+				elem.WriteByte(body[i])
+			}
+		}
+		s := elem.String()
+		if !quoted && s == "NULL" {
+			elems = append(elems, nil)
+		} else {
+			elems = append(elems, &s)
+		}
+		if i < len(body) && body[i] != ',' {
+			return nil, fmt.Errorf("invalid array %q", text)
+		}
+		i++
+	}
+	return elems, nil
+}
@@ -21,6 +21,48 @@ func getTempScanDest(scanType reflect.Type) any {
 	}
 }
 
+// ScanMode NULL值和多余列的处理方式
+type ScanMode int
+
+const (
+	ScanDefault  ScanMode = iota // 未设置, 方法上使用全局的扫描模式, 全局未设置时与ScanStandard相同
+	ScanStandard                 // 与database/sql一致, NULL扫描到非指针类型时报错, 多余的列忽略
+	ScanLenient                  // NULL扫描到非指针类型时设置为零值
+	ScanStrict                   // NULL扫描到非指针类型或者列没有对应字段时报错
+)
+
+// ParseScanMode 空字符串为未设置, default为ScanStandard, 可以在方法上覆盖全局的扫描模式
+func ParseScanMode(s string) (ScanMode, error) {
+	switch s {
+	case "":
+		return ScanDefault, nil
+	case "default", "standard":
+		return ScanStandard, nil
+	case "lenient":
+		return ScanLenient, nil
+	case "strict":
+		return ScanStrict, nil
+	}
+	return ScanDefault, fmt.Errorf("scan mode %s not support", s)
+}
+
+type ScanOption struct {
+	FiledName func(t reflect.Type, name string) string
+	Mode      ScanMode
+}
+
+// notNullable 判断类型不能直接接收NULL值
+func notNullable(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(scannerType) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return false
+	}
+	return true
+}
+
 func setMapValue(t reflect.Type, ret []reflect.Value, isSlice bool) (v reflect.Value, err error) {
 	if t.Key().Kind() != reflect.String {
 		err = fmt.Errorf("map key must be string")
@@ -84,6 +126,55 @@ func setValue(t reflect.Type, ret []reflect.Value, isSlice bool) (v reflect.Valu
 	return
 }
 func GetScanDest(filedName func(t reflect.Type, name string) string, columns []*sql.ColumnType, ret []reflect.Value) (destSlice []any, deferFn []func(), err error) {
+	destSlice, deferFn, _, err = getScanDest(ScanOption{FiledName: filedName}, columns, ret)
+	return
+}
+
+// GetScanDestOption 按照扫描模式获取扫描目标, rows.Scan之后调用afterScan设置结果
+func GetScanDestOption(opt ScanOption, columns []*sql.ColumnType, ret []reflect.Value) (destSlice []any, afterScan func() error, err error) {
+	destSlice, deferFn, check, err := getScanDest(opt, columns, ret)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterScan = func() error {
+		for _, fn := range deferFn {
+			fn()
+		}
+		return check()
+	}
+	return destSlice, afterScan, nil
+}
+
+func getScanDest(opt ScanOption, columns []*sql.ColumnType, ret []reflect.Value) (destSlice []any, deferFn []func(), check func() error, err error) {
+	filedName := opt.FiledName
+	var nullErr error
+	check = func() error {
+		return nullErr
+	}
+	// addr 返回x的扫描地址, 非默认模式下不能为NULL的类型先扫描到指针中再处理NULL
+	addr := func(x reflect.Value, c *sql.ColumnType, field string) any {
+		if opt.Mode == ScanDefault || opt.Mode == ScanStandard || !notNullable(x.Type()) {
+			return x.Addr().Interface()
+		}
+		holder := reflect.New(reflect.PointerTo(x.Type()))
+		deferFn = append(deferFn, func() {
+			if p := holder.Elem(); !p.IsNil() {
+				x.Set(p.Elem())
+				return
+			}
+			x.SetZero()
+			if opt.Mode == ScanStrict && nullErr == nil {
+				nullErr = fmt.Errorf("column %s is NULL, can't scan into %s", c.Name(), field)
+			}
+		})
+		return holder.Interface()
+	}
+	noDest := func(c *sql.ColumnType, where string) any {
+		if opt.Mode == ScanStrict && err == nil {
+			err = fmt.Errorf("column %s has no destination field in %s", c.Name(), where)
+		}
+		return getTempScanDest(c.ScanType())
+	}
 	if len(ret) == 0 {
 		err = fmt.Errorf("not scan dest")
 		return
@@ -118,10 +209,11 @@ func GetScanDest(filedName func(t reflect.Type, name string) string, columns []*
 			case reflect.Map:
 				valT := v.Type().Elem()
 				val := reflect.New(valT).Elem()
+				dest := addr(val, c, fmt.Sprintf("%s[%q]", v.Type(), c.Name()))
 				deferFn = append(deferFn, func() {
 					v.SetMapIndex(reflect.ValueOf(c.Name()), val)
 				})
-				destSlice = append(destSlice, val.Addr().Interface())
+				destSlice = append(destSlice, dest)
 			case reflect.Struct:
 				if i == 0 && isScanValJson(c) {
 					ScanVal := ShouldScanValJson(c, v)
@@ -134,7 +226,7 @@ func GetScanDest(filedName func(t reflect.Type, name string) string, columns []*
 					fname := filedName(v.Type(), c.Name())
 					fv := v.FieldByName(fname)
 					if !fv.IsValid() || !fv.CanSet() {
-						destSlice = append(destSlice, getTempScanDest(c.ScanType()))
+						destSlice = append(destSlice, noDest(c, v.Type().String()))
 						continue
 					}
 					if isScanVal(fv.Type()) {
@@ -149,14 +241,18 @@ func GetScanDest(filedName func(t reflect.Type, name string) string, columns []*
 							}
 						})
 					} else {
-						ScanVal := ShouldScanValJson(c, fv)
-						if isScanValJson(c) {
+						sf, _ := v.Type().FieldByName(fname)
+						if useScanValArray(c, fv.Type()) {
+							ScanVal := newScanValArray(fv)
+							destSlice = append(destSlice, ScanVal.Interface())
+						} else if useScanValJson(c, fv.Type(), &sf) {
+							ScanVal := newScanValJson(fv)
 							destSlice = append(destSlice, ScanVal.Interface())
 							deferFn = append(deferFn, func() {
 								fv.Set(getScanValJson(ScanVal))
 							})
 						} else {
-							destSlice = append(destSlice, fv.Addr().Interface())
+							destSlice = append(destSlice, addr(fv, c, v.Type().String()+"."+fname))
 						}
 					}
 					continue
@@ -164,17 +260,19 @@ func GetScanDest(filedName func(t reflect.Type, name string) string, columns []*
 				fallthrough
 			default:
 				if i == 0 && v.CanSet() {
-					if isScanValJson(c) {
-						ScanVal := ShouldScanValJson(c, v)
+					if useScanValArray(c, v.Type()) {
+						destSlice = append(destSlice, newScanValArray(v).Interface())
+					} else if useScanValJson(c, v.Type(), nil) {
+						ScanVal := newScanValJson(v)
 						destSlice = append(destSlice, ScanVal.Interface())
 						deferFn = append(deferFn, func() {
 							v.Set(getScanValJson(ScanVal))
 						})
 					} else {
-						destSlice = append(destSlice, v.Addr().Interface())
+						destSlice = append(destSlice, addr(v, c, v.Type().String()))
 					}
 				} else {
-					destSlice = append(destSlice, getTempScanDest(c.ScanType()))
+					destSlice = append(destSlice, noDest(c, v.Type().String()))
 				}
 			}
 		}
@@ -196,18 +294,22 @@ func GetScanDest(filedName func(t reflect.Type, name string) string, columns []*
 								ret[i] = getScanVal(scanV)
 							}
 						})
-					case isScanValJson(columns[i]):
-						ScanVal := ShouldScanValJson(columns[i], ret[i])
-						destSlice = append(destSlice, ScanVal.Addr().Interface())
+					case useScanValArray(columns[i], t):
+						ret[i] = reflect.New(t).Elem()
+						destSlice = append(destSlice, newScanValArray(ret[i]).Interface())
+					case useScanValJson(columns[i], t, nil):
+						ret[i] = reflect.New(t).Elem()
+						ScanVal := newScanValJson(ret[i])
+						destSlice = append(destSlice, ScanVal.Interface())
 						deferFn = append(deferFn, func() {
 							ret[i] = getScanValJson(ScanVal)
 						})
 					default:
 						ret[i] = reflect.New(t).Elem()
-						destSlice = append(destSlice, ret[i].Addr().Interface())
+						destSlice = append(destSlice, addr(ret[i], columns[i], t.String()))
 					}
 				} else {
-					destSlice = append(destSlice, getTempScanDest(columns[i].ScanType()))
+					destSlice = append(destSlice, noDest(columns[i], "func results"))
 				}
 			}
 		}
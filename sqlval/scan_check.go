@@ -0,0 +1,119 @@
+package sqlval
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// HasTagOption 判断结构体字段的db标签是否包含选项, 例如`db:",required"`
+func HasTagOption(f reflect.StructField, option string) bool {
+	_, opts, ok := strings.Cut(f.Tag.Get("db"), ",")
+	if !ok {
+		return false
+	}
+	for _, v := range strings.Split(opts, ",") {
+		if strings.TrimSpace(v) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// IsScanVal 判断类型是否注册了ScanVal
+func IsScanVal(t reflect.Type) bool {
+	return isScanVal(t)
+}
+
+// IsScanJson 判断列是否按照json解析
+func IsScanJson(c *sql.ColumnType) bool {
+	return isScanValJson(c)
+}
+
+type scanKind int
+
+const (
+	scanUnknown scanKind = iota
+	scanNumber
+	scanString
+	scanBool
+	scanTime
+)
+
+var (
+	scannerType = reflect.TypeFor[sql.Scanner]()
+	timeType    = reflect.TypeFor[time.Time]()
+	bytesType   = reflect.TypeFor[[]byte]()
+)
+
+var nullScanKind = map[reflect.Type]scanKind{
+	reflect.TypeFor[sql.NullInt64]():   scanNumber,
+	reflect.TypeFor[sql.NullInt32]():   scanNumber,
+	reflect.TypeFor[sql.NullInt16]():   scanNumber,
+	reflect.TypeFor[sql.NullByte]():    scanNumber,
+	reflect.TypeFor[sql.NullFloat64](): scanNumber,
+	reflect.TypeFor[sql.NullString]():  scanString,
+	reflect.TypeFor[sql.NullBool]():    scanBool,
+	reflect.TypeFor[sql.NullTime]():    scanTime,
+	reflect.TypeFor[sql.RawBytes]():    scanString,
+}
+
+func typeScanKind(t reflect.Type) scanKind {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if k, ok := nullScanKind[t]; ok {
+		return k
+	}
+	if t == timeType {
+		return scanTime
+	}
+	if t == bytesType {
+		return scanString
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return scanNumber
+	case reflect.String:
+		return scanString
+	case reflect.Bool:
+		return scanBool
+	}
+	return scanUnknown
+}
+
+// ScanCompatible 判断数据库列能否扫描到Go类型, 无法判断的情况返回true
+func ScanCompatible(c *sql.ColumnType, t reflect.Type) bool {
+	if t.Kind() == reflect.Interface || isScanVal(t) || useScanValArray(c, t) || useScanValJson(c, t, nil) {
+		return true
+	}
+	if t.Implements(scannerType) || reflect.PointerTo(t).Implements(scannerType) {
+		return true
+	}
+	st := c.ScanType()
+	if st == nil {
+		return true
+	}
+	from, to := typeScanKind(st), typeScanKind(t)
+	if from == scanUnknown {
+		return true
+	}
+	switch to {
+	case scanString:
+		return true
+	case scanNumber:
+		if from == scanString {
+			name := strings.ToUpper(c.DatabaseTypeName())
+			return strings.Contains(name, "DECIMAL") || strings.Contains(name, "NUMERIC")
+		}
+		return from == scanNumber || from == scanBool
+	case scanBool:
+		return from == scanBool || from == scanNumber
+	case scanTime:
+		return from == scanTime
+	}
+	return false
+}
@@ -5,23 +5,100 @@ import (
 	"encoding/json"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 type ScanValJson struct {
 	Val reflect.Value
 }
 
+var (
+	jsonDatabaseTypeMu sync.RWMutex
+	jsonDatabaseType   = map[string]struct{}{
+		"JSON":  {},
+		"JSONB": {},
+	}
+)
+
+// RegisterJsonDatabaseType 注册按照json解析的数据库类型名称, 默认包含JSON和JSONB
+func RegisterJsonDatabaseType(names ...string) {
+	jsonDatabaseTypeMu.Lock()
+	defer jsonDatabaseTypeMu.Unlock()
+	for _, name := range names {
+		jsonDatabaseType[strings.ToUpper(name)] = struct{}{}
+	}
+}
+
 func isScanValJson(columns *sql.ColumnType) bool {
-	return strings.ToLower(columns.DatabaseTypeName()) == "json"
+	jsonDatabaseTypeMu.RLock()
+	defer jsonDatabaseTypeMu.RUnlock()
+	_, ok := jsonDatabaseType[strings.ToUpper(columns.DatabaseTypeName())]
+	return ok
+}
+
+// isBytesType []byte以及sql.RawBytes、json.RawMessage等字节切片类型, 直接扫描原始值
+func isBytesType(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// isTextColumn 判断列的值是文本或者字节
+func isTextColumn(c *sql.ColumnType) bool {
+	if st := c.ScanType(); st != nil && typeScanKind(st) == scanString {
+		return true
+	}
+	name := strings.ToUpper(c.DatabaseTypeName())
+	for _, v := range []string{"CHAR", "TEXT", "CLOB", "BLOB", "BINARY"} {
+		if strings.Contains(name, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// isJsonDestType 与参数自动json序列化对应, 结构体、map、切片和数组类型使用json解析
+func isJsonDestType(t reflect.Type) bool {
+	if _, ok := localConvertValMap[t]; ok || isScanVal(t) {
+		return false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType || isBytesType(t) || reflect.PointerTo(t).Implements(scannerType) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	}
+	return false
+}
+
+// useScanValJson 判断列扫描到类型t时是否按照json解析:
+// 字段标签`db:",json"`, 注册的json数据库类型, 或者文本列扫描到结构体、map、切片
+func useScanValJson(c *sql.ColumnType, t reflect.Type, field *reflect.StructField) bool {
+	if field != nil && HasTagOption(*field, "json") {
+		return true
+	}
+	if isScanValJson(c) {
+		return !isBytesType(t)
+	}
+	return isJsonDestType(t) && isTextColumn(c) && !isArrayColumn(c)
+}
+
+func newScanValJson(val reflect.Value) reflect.Value {
+	scanVal := reflect.New(reflect.TypeOf(ScanValJson{}))
+	if s, ok := scanVal.Interface().(*ScanValJson); ok {
+		s.Val = val
+	}
+	return scanVal
 }
 
 func ShouldScanValJson(columns *sql.ColumnType, val reflect.Value) reflect.Value {
 	if isScanValJson(columns) {
-		scanVal := reflect.New(reflect.TypeOf(ScanValJson{}))
-		if s, ok := scanVal.Interface().(*ScanValJson); ok {
-			s.Val = val
-		}
-		return scanVal
+		return newScanValJson(val)
 	}
 	return val
 }
@@ -30,22 +107,29 @@ func (s *ScanValJson) Scan(src any) error {
 	if src == nil {
 		return nil
 	}
-	if srcBytes, ok := src.([]byte); ok {
-		if len(srcBytes) == 0 {
-			return nil
-		}
-		if s.Val.Kind() == reflect.Pointer {
-			if err := json.Unmarshal(srcBytes, s.Val.Interface()); err != nil {
-				return err
-			}
-		} else {
-			if s.Val.CanAddr() {
-				if err := json.Unmarshal(srcBytes, s.Val.Addr().Interface()); err != nil {
-					return err
-				}
+	var srcBytes []byte
+	switch v := src.(type) {
+	case []byte:
+		srcBytes = v
+	case string:
+		srcBytes = []byte(v)
+	default:
+		return nil
+	}
+	if len(srcBytes) == 0 {
+		return nil
+	}
+	if s.Val.Kind() == reflect.Pointer {
+		if s.Val.IsNil() {
+			if !s.Val.CanSet() {
+				return nil
 			}
+			s.Val.Set(reflect.New(s.Val.Type().Elem()))
 		}
-		return nil
+		return json.Unmarshal(srcBytes, s.Val.Interface())
+	}
+	if s.Val.CanAddr() {
+		return json.Unmarshal(srcBytes, s.Val.Addr().Interface())
 	}
 	return nil
 }
@@ -5,8 +5,11 @@ import (
 )
 
 type SqlWrite struct {
-	sql  strings.Builder
-	args []any
+	sql    strings.Builder
+	args   []any
+	params []int // 参数占位符?在sql中的位置
+	// in函数的参数是空切片
+	emptyIn bool
 }
 
 func (s *SqlWrite) Write(p []byte) (n int, err error) {
@@ -27,16 +30,101 @@ func (s *SqlWrite) WriteString(str string) (n int, err error) {
 	return
 }
 
+// WriteParam 写入sql并将其中的?绑定到arg, arg为nil时绑定NULL, arg为*SqlWrite时合并它的sql和参数
 func (s *SqlWrite) WriteParam(sql string, arg any) {
-	if arg == nil {
-		return
-	}
+	offset := s.sql.Len()
 	if sqw, ok := arg.(*SqlWrite); ok {
+		if sqw == nil {
+			return
+		}
+		s.emptyIn = s.emptyIn || sqw.emptyIn
 		s.args = append(s.args, sqw.Args()...)
+		for _, p := range sqw.params {
+			s.params = append(s.params, offset+p)
+		}
 		s.sql.WriteString(sqw.Sql())
 		return
 	} else {
+		for i := 0; i < len(sql); i++ {
+			if sql[i] == '?' {
+				s.params = append(s.params, offset+i)
+			}
+		}
 		s.sql.WriteString(sql)
 		s.args = append(s.args, arg)
 	}
 }
+
+// MarkEmptyIn 标记sql中有参数为空切片的in
+func (s *SqlWrite) MarkEmptyIn() {
+	s.emptyIn = true
+}
+
+func (s *SqlWrite) EmptyIn() bool {
+	return s.emptyIn
+}
+
+// Rebind 将参数占位符?替换为placeholder(n)返回的占位符, n从1开始, sql文本中的其他?保持不变
+func (s *SqlWrite) Rebind(placeholder func(n int) string) string {
+	sql := s.sql.String()
+	if len(s.params) == 0 {
+		return sql
+	}
+	sb := strings.Builder{}
+	prev := 0
+	for i, p := range s.params {
+		sb.WriteString(sql[prev:p])
+		sb.WriteString(placeholder(i + 1))
+		prev = p + 1
+	}
+	sb.WriteString(sql[prev:])
+	return sb.String()
+}
+
+// TrimClause 返回去掉首尾空白、开头的prefixes之一和结尾的suffixes之一后的SqlWrite,
+// 前缀后缀不区分大小写, 是单词时要求完整匹配(and不会匹配android)
+func (s *SqlWrite) TrimClause(prefixes, suffixes []string) *SqlWrite {
+	sql := s.sql.String()
+	start, end := trimSpace(sql, 0, len(sql))
+	for _, p := range prefixes {
+		if end-start >= len(p) && strings.EqualFold(sql[start:start+len(p)], p) &&
+			!(isWord(p[len(p)-1]) && start+len(p) < end && isWord(sql[start+len(p)])) {
+			start += len(p)
+			break
+		}
+	}
+	for _, p := range suffixes {
+		if end-start >= len(p) && strings.EqualFold(sql[end-len(p):end], p) &&
+			!(isWord(p[0]) && end-len(p) > start && isWord(sql[end-len(p)-1])) {
+			end -= len(p)
+			break
+		}
+	}
+	start, end = trimSpace(sql, start, end)
+	ret := &SqlWrite{args: s.args, emptyIn: s.emptyIn}
+	ret.sql.WriteString(sql[start:end])
+	for _, p := range s.params {
+		if p >= start && p < end {
+			ret.params = append(ret.params, p-start)
+		}
+	}
+	return ret
+}
+
+func trimSpace(s string, start, end int) (int, int) {
+	for start < end && isSpace(s[start]) {
+		start++
+	}
+	for end > start && isSpace(s[end-1]) {
+		end--
+	}
+	return start, end
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isWord(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
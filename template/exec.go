@@ -41,6 +41,8 @@ type state struct {
 	node  parse.Node // current node, for errors
 	vars  []variable // push-down stack of variable values.
 	depth int        // the height of the stack of executing templates.
+	// 最近一次取值时map中不存在的key, 输出到sql时报错而不是绑定NULL
+	missingKey string
 }
 
 // variable holds the dynamic value of a variable such as $, $x etc.
@@ -293,11 +295,38 @@ func (s *state) walk(dot reflect.Value, node parse.Node) {
 		}
 	case *parse.WithNode:
 		s.walkIfOrWith(parse.NodeWith, dot, node.Pipe, node.List, node.ElseList)
+	case *parse.ClauseNode:
+		s.walkClause(dot, node)
 	default:
 		s.errorf("unknown node: %s", node)
 	}
 }
 
+// walkClause 输出{where}、{setblock}子句块, 内容去掉开头的and/or或者多余的逗号, 为空时不输出关键字
+func (s *state) walkClause(dot reflect.Value, c *parse.ClauseNode) {
+	s.at(c)
+	sqw, ok := s.wr.(*sqlwrite.SqlWrite)
+	if !ok {
+		s.errorf("{%s} requires sql output", c.Name)
+	}
+	sub := &sqlwrite.SqlWrite{}
+	s.wr = sub
+	s.walk(dot, c.List)
+	s.wr = sqw
+	var clause *sqlwrite.SqlWrite
+	if c.Keyword == "set" {
+		clause = sub.TrimClause([]string{","}, []string{","})
+	} else {
+		clause = sub.TrimClause([]string{"and", "or"}, nil)
+	}
+	if clause.Sql() == "" {
+		return
+	}
+	sqw.WriteString(c.Keyword + " ")
+	sqw.WriteParam("", clause)
+	sqw.WriteString(" ")
+}
+
 // walkIfOrWith walks an 'if' or 'with' node. The two control structures
 // are identical in behavior except that 'with' sets dot.
 func (s *state) walkIfOrWith(typ parse.NodeType, dot reflect.Value, pipe *parse.PipeNode, list, elseList *parse.ListNode) {
@@ -692,6 +721,7 @@ func (s *state) evalFunction(dot reflect.Value, node *parse.IdentifierNode, cmd
 // The 'final' argument represents the return value from the preceding
 // value of the pipeline, if any.
 func (s *state) evalField(dot reflect.Value, fieldName string, node parse.Node, args []parse.Node, final, receiver reflect.Value) reflect.Value {
+	s.missingKey = ""
 	if !receiver.IsValid() {
 		if s.tmpl.option.missingKey == mapError { // Treat invalid value as missing map key.
 			s.errorf("nil data; no entry for key %q", fieldName)
@@ -747,6 +777,7 @@ func (s *state) evalField(dot reflect.Value, fieldName string, node parse.Node,
 				s.errorf("%s is not a method but has arguments", fieldName)
 			}
 			result := receiver.MapIndex(nameVal)
+			key := fieldName
 			// 再次尝试使用转换后的字段名
 			if !result.IsValid() {
 				if s.tmpl.filedName != nil {
@@ -759,6 +790,7 @@ func (s *state) evalField(dot reflect.Value, fieldName string, node parse.Node,
 				switch s.tmpl.option.missingKey {
 				case mapInvalid:
 					// Just use the invalid value.
+					s.missingKey = key
 				case mapZeroValue:
 					result = reflect.Zero(receiver.Type().Elem())
 				case mapError:
@@ -790,6 +822,7 @@ func (s *state) evalField(dot reflect.Value, fieldName string, node parse.Node,
 var (
 	errorType        = reflect.TypeFor[error]()
 	fmtStringerType  = reflect.TypeFor[fmt.Stringer]()
+	sqlWriteType     = reflect.TypeFor[*sqlwrite.SqlWrite]()
 	reflectValueType = reflect.TypeFor[reflect.Value]()
 )
 
@@ -1127,6 +1160,21 @@ func indirectInterface(v reflect.Value) reflect.Value {
 // the template.
 func (s *state) printValue(n parse.Node, v reflect.Value) {
 	s.at(n)
+	// sql函数返回的*SqlWrite直接合并sql和参数
+	if sqw, ok := s.wr.(*sqlwrite.SqlWrite); ok && v.IsValid() && v.Type() == sqlWriteType {
+		if !v.IsNil() {
+			sqw.WriteParam("", v.Interface())
+		}
+		return
+	}
+	// 没有值(例如map中值为nil)时绑定NULL, map中不存在的key可能是拼写错误, 返回错误
+	if sqw, ok := s.wr.(*sqlwrite.SqlWrite); ok && !v.IsValid() {
+		if s.missingKey != "" {
+			s.errorf("map has no entry for key %q", s.missingKey)
+		}
+		sqw.WriteParam("? ", nil)
+		return
+	}
 	iface, ok := printableValue(v)
 	if !ok {
 		s.errorf("can't print %s of type %s", n, v.Type())
@@ -0,0 +1,26 @@
+package template
+
+import "github.com/tianxinzizhen/tgsql/template/parse"
+
+// Expansion 模板预处理的调试信息
+type Expansion struct {
+	Text string // @、[]、?和insert简写改写后的模板文本
+	Tree string // 解析树摘要, 每行一个节点, 行:列为改写前sql中的位置
+}
+
+// Expand 返回text在leftDelim、rightDelim分隔符下预处理改写后的模板文本和解析树摘要, funcs为模板中使用的函数
+func Expand(text, leftDelim, rightDelim string, funcs FuncMap) (*Expansion, error) {
+	expanded, tree, err := parse.Expand(text, leftDelim, rightDelim, 0, funcs, builtins())
+	if err != nil {
+		return nil, err
+	}
+	return &Expansion{Text: expanded, Tree: tree.Summary()}, nil
+}
+
+// Expand 使用t的分隔符和函数预处理text, 不会把text加入到t中
+func (t *Template) Expand(text string) (*Expansion, error) {
+	t.init()
+	t.muFuncs.RLock()
+	defer t.muFuncs.RUnlock()
+	return Expand(text, t.leftDelim, t.rightDelim, t.parseFuncs)
+}
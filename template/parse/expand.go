@@ -0,0 +1,69 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expand 返回text经过@、[]、?和insert简写预处理改写后的模板文本和解析树, 用于调试简写语法
+func Expand(text, leftDelim, rightDelim string, mode Mode, funcs ...map[string]any) (expanded string, tree *Tree, err error) {
+	const name = "expand"
+	trees, err := ParseMode(name, text, leftDelim, rightDelim, mode, Source{}, funcs...)
+	if err != nil {
+		return "", nil, err
+	}
+	if leftDelim == "" {
+		leftDelim = "{{"
+	}
+	if rightDelim == "" {
+		rightDelim = "}}"
+	}
+	t := New(name, funcs...)
+	expanded, _, _ = handleAtsign(text, leftDelim, rightDelim, mode, t.hasFunction)
+	return expanded, trees[name], nil
+}
+
+// Summary 返回解析树的摘要, 每行一个节点, 格式为"原始文本中的行:列 节点", 子节点缩进两个空格
+func (t *Tree) Summary() string {
+	sb := &strings.Builder{}
+	t.summary(sb, t.Root, 0)
+	return sb.String()
+}
+
+func (t *Tree) summary(sb *strings.Builder, list *ListNode, depth int) {
+	if list == nil {
+		return
+	}
+	for _, n := range list.Nodes {
+		line, col := t.SourcePos(n)
+		fmt.Fprintf(sb, "%s%d:%d ", strings.Repeat("  ", depth), line, col)
+		switch n := n.(type) {
+		case *TextNode:
+			fmt.Fprintf(sb, "text %q\n", n.Text)
+		case *IfNode:
+			fmt.Fprintf(sb, "if %s\n", n.Pipe)
+			t.branchSummary(sb, &n.BranchNode, depth)
+		case *RangeNode:
+			fmt.Fprintf(sb, "range %s\n", n.Pipe)
+			t.branchSummary(sb, &n.BranchNode, depth)
+		case *WithNode:
+			fmt.Fprintf(sb, "with %s\n", n.Pipe)
+			t.branchSummary(sb, &n.BranchNode, depth)
+		case *ActionNode:
+			fmt.Fprintf(sb, "action %s\n", n.Pipe)
+		case *ClauseNode:
+			fmt.Fprintf(sb, "%s\n", n.Name)
+			t.summary(sb, n.List, depth+1)
+		default:
+			fmt.Fprintf(sb, "%s\n", n)
+		}
+	}
+}
+
+func (t *Tree) branchSummary(sb *strings.Builder, b *BranchNode, depth int) {
+	t.summary(sb, b.List, depth+1)
+	if b.ElseList != nil {
+		fmt.Fprintf(sb, "%selse\n", strings.Repeat("  ", depth))
+		t.summary(sb, b.ElseList, depth+1)
+	}
+}
@@ -124,6 +124,8 @@ type lexer struct {
 	item         item   // item to return to parser
 	insideAction bool   // are we inside an action?
 	options      lexOptions
+	marks        []srcMark // 预处理后位置到原始位置的对应
+	preErr       *item     // 预处理的错误, 位置为原始输入中的位置
 }
 
 // lexOptions control behavior of the lexer. All default to false.
@@ -224,6 +226,10 @@ func (l *lexer) errorf(format string, args ...any) stateFn {
 // nextItem returns the next item from the input.
 // Called by the parser, not in the lexing goroutine.
 func (l *lexer) nextItem() item {
+	if l.preErr != nil {
+		l.item, l.preErr = *l.preErr, nil
+		return l.item
+	}
 	l.item = item{itemEOF, l.pos, "EOF", l.startLine}
 	state := lexText
 	if l.insideAction {
@@ -238,7 +244,7 @@ func (l *lexer) nextItem() item {
 }
 
 // lex creates a new scanner for the input string.
-func lex(name, input, left, right string, hasFunction func(name string) bool) *lexer {
+func lex(name, input, left, right string, mode Mode, hasFunction func(name string) bool) *lexer {
 	if left == "" {
 		left = leftDelim
 	}
@@ -246,16 +252,20 @@ func lex(name, input, left, right string, hasFunction func(name string) bool) *l
 		right = rightDelim
 	}
 	// 处理input中@信息
-	input = handleAtsign(input, left, right, hasFunction)
+	body, marks, err := handleAtsign(input, left, right, mode, hasFunction)
 	l := &lexer{
 		name:         name,
-		input:        input,
+		input:        body,
+		marks:        marks,
 		leftDelim:    left,
 		rightDelim:   right,
 		line:         1,
 		startLine:    1,
 		insideAction: false,
 	}
+	if err != nil {
+		l.preErr = &item{typ: itemError, pos: Pos(len(input) - err.rest), val: err.msg, line: 1}
+	}
 	return l
 }
 
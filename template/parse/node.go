@@ -73,6 +73,7 @@ const (
 	NodeComment                    // A comment.
 	NodeBreak                      // A break action.
 	NodeContinue                   // A continue action.
+	NodeClause                     // {where}、{setblock}子句块
 )
 
 // Nodes.
@@ -972,6 +973,44 @@ func (w *WithNode) Copy() Node {
 	return w.tr.newWith(w.Pos, w.Line, w.Pipe.CopyPipe(), w.List.CopyList(), w.ElseList.CopyList())
 }
 
+// ClauseNode {where}...{end}和{setblock}...{end}子句块, 输出时去掉内容开头的and/or或者多余的逗号,
+// 内容为空时不输出关键字
+type ClauseNode struct {
+	NodeType
+	Pos
+	tr      *Tree
+	Line    int       // The line number in the input. Deprecated: Kept for compatibility.
+	Name    string    // where或者setblock
+	Keyword string    // 输出的sql关键字where或者set
+	List    *ListNode // 子句的内容
+}
+
+func (t *Tree) newClause(pos Pos, line int, name string, list *ListNode) *ClauseNode {
+	return &ClauseNode{tr: t, NodeType: NodeClause, Pos: pos, Line: line, Name: name, Keyword: clauseKeyword[name], List: list}
+}
+
+func (c *ClauseNode) String() string {
+	var sb strings.Builder
+	c.writeTo(&sb)
+	return sb.String()
+}
+
+func (c *ClauseNode) writeTo(sb *strings.Builder) {
+	sb.WriteString("{{")
+	sb.WriteString(c.Name)
+	sb.WriteString("}}")
+	c.List.writeTo(sb)
+	sb.WriteString("{{end}}")
+}
+
+func (c *ClauseNode) tree() *Tree {
+	return c.tr
+}
+
+func (c *ClauseNode) Copy() Node {
+	return c.tr.newClause(c.Pos, c.Line, c.Name, c.List.CopyList())
+}
+
 // TemplateNode represents a {{template}} action.
 type TemplateNode struct {
 	NodeType
@@ -12,6 +12,7 @@ import (
 	"bytes"
 	"fmt"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -22,7 +23,9 @@ type Tree struct {
 	ParseName string    // name of the top-level template during parsing, for error messages.
 	Root      *ListNode // top-level root of the tree.
 	Mode      Mode      // parsing mode.
+	Source    Source    // 模板在Go源文件中的位置, 用于错误信息
 	text      string    // text parsed to create the template (or its parent)
+	marks     []srcMark // 预处理后位置到原始text位置的对应
 	// Parsing only; cleared after parse.
 	funcs      []map[string]any
 	lex        *lexer
@@ -34,14 +37,45 @@ type Tree struct {
 	rangeDepth int
 }
 
+// Source 模板第一个字符在Go源文件中的位置, File为空时错误信息使用模板名称和模板中的行列
+type Source struct {
+	File string
+	Line int
+	Col  int
+}
+
 // A mode value is a set of flags (or 0). Modes control parser behavior.
 type Mode uint
 
 const (
 	ParseComments Mode = 1 << iota // parse comments and add them to AST
 	SkipFuncCheck                  // do not check that functions are defined
+	RawAtsign                      // @不作为字段引用
+	RawQuestion                    // ?不作为参数
+	RawBracket                     // []不作为可选条件
 )
 
+// RawSymbolsMode 将?option{raw_symbols:...}的值转换为Mode, true表示@、?、[]都不替换, 否则为符号列表, 例如@?
+func RawSymbolsMode(symbols string) (Mode, error) {
+	if symbols == "true" {
+		return RawAtsign | RawQuestion | RawBracket, nil
+	}
+	var mode Mode
+	for _, r := range symbols {
+		switch r {
+		case '@':
+			mode |= RawAtsign
+		case '?':
+			mode |= RawQuestion
+		case '[', ']':
+			mode |= RawBracket
+		default:
+			return 0, fmt.Errorf("raw_symbols %q not support symbol %q", symbols, r)
+		}
+	}
+	return mode, nil
+}
+
 // Copy returns a copy of the [Tree]. Any parsing state is discarded.
 func (t *Tree) Copy() *Tree {
 	if t == nil {
@@ -51,7 +85,9 @@ func (t *Tree) Copy() *Tree {
 		Name:      t.Name,
 		ParseName: t.ParseName,
 		Root:      t.Root.CopyList(),
+		Source:    t.Source,
 		text:      t.text,
+		marks:     t.marks,
 	}
 }
 
@@ -60,8 +96,15 @@ func (t *Tree) Copy() *Tree {
 // given the specified name. If an error is encountered, parsing stops and an
 // empty map is returned with the error.
 func Parse(name, text, leftDelim, rightDelim string, funcs ...map[string]any) (map[string]*Tree, error) {
+	return ParseMode(name, text, leftDelim, rightDelim, 0, Source{}, funcs...)
+}
+
+// ParseMode 与Parse相同, 使用mode解析模板, 错误信息中的位置按照src换算到Go源文件
+func ParseMode(name, text, leftDelim, rightDelim string, mode Mode, src Source, funcs ...map[string]any) (map[string]*Tree, error) {
 	treeSet := make(map[string]*Tree)
 	t := New(name)
+	t.Mode = mode
+	t.Source = src
 	t.text = text
 	_, err := t.Parse(text, leftDelim, rightDelim, treeSet, funcs...)
 	return treeSet, err
@@ -139,28 +182,51 @@ func New(name string, funcs ...map[string]any) *Tree {
 // The receiver is only used when the node does not have a pointer to the tree inside,
 // which can occur in old code.
 func (t *Tree) ErrorContext(n Node) (location, context string) {
-	pos := int(n.Position())
 	tree := n.tree()
 	if tree == nil {
 		tree = t
 	}
-	text := tree.text[:pos]
-	byteNum := strings.LastIndex(text, "\n")
-	if byteNum == -1 {
-		byteNum = pos // On first line.
-	} else {
-		byteNum++ // After the newline.
-		byteNum = pos - byteNum
+	return tree.location(n.Position()), n.String()
+}
+
+// SourcePos 返回节点在原始模板文本中的行号和列号(从1开始), @和[]等预处理改写的节点定位到改写前的位置
+func (t *Tree) SourcePos(n Node) (line, col int) {
+	tree := n.tree()
+	if tree == nil {
+		tree = t
 	}
-	lineNum := 1 + strings.Count(text, "\n")
-	context = n.String()
-	return fmt.Sprintf("%s:%d:%d", tree.ParseName, lineNum, byteNum), context
+	return tree.sourcePos(n.Position())
+}
+
+// sourcePos 将预处理后文本中的位置换算为原始模板文本中的行号和列号
+func (t *Tree) sourcePos(pos Pos) (line, col int) {
+	i := sort.Search(len(t.marks), func(i int) bool { return t.marks[i].out > pos }) - 1
+	if i >= 0 {
+		pos = t.marks[i].in
+	}
+	pos = min(pos, Pos(len(t.text)))
+	text := t.text[:pos]
+	line = 1 + strings.Count(text, "\n")
+	col = int(pos) - strings.LastIndex(text, "\n")
+	return line, col
+}
+
+// location 返回错误信息中的位置, 设置了Source时为Go源文件中的file:line:col, 否则为模板名称:line:col
+func (t *Tree) location(pos Pos) string {
+	line, col := t.sourcePos(pos)
+	if t.Source.File == "" {
+		return fmt.Sprintf("%s:%d:%d", t.ParseName, line, col)
+	}
+	if line == 1 {
+		col += t.Source.Col - 1
+	}
+	return fmt.Sprintf("%s:%d:%d", t.Source.File, t.Source.Line+line-1, col)
 }
 
 // errorf formats the error and terminates processing.
 func (t *Tree) errorf(format string, args ...any) {
 	t.Root = nil
-	format = fmt.Sprintf("template: %s:%d: %s", t.ParseName, t.token[0].line, format)
+	format = fmt.Sprintf("template: %s: %s", t.location(t.token[0].pos), format)
 	panic(fmt.Errorf(format, args...))
 }
 
@@ -245,9 +311,12 @@ func (t *Tree) stopParse() {
 func (t *Tree) Parse(text, leftDelim, rightDelim string, treeSet map[string]*Tree, funcs ...map[string]any) (tree *Tree, err error) {
 	defer t.recover(&err)
 	t.ParseName = t.Name
-	lexer := lex(t.Name, text, leftDelim, rightDelim, t.hasFunction)
+	// 预处理@和{}时需要判断函数是否存在
+	t.funcs = funcs
+	lexer := lex(t.Name, text, leftDelim, rightDelim, t.Mode, t.hasFunction)
 	t.startParse(funcs, lexer, treeSet)
 	t.text = text
+	t.marks = lexer.marks
 	t.parse()
 	t.add()
 	t.stopParse()
@@ -274,6 +343,7 @@ func IsEmptyTree(n Node) bool {
 	case *ActionNode:
 	case *CommentNode:
 		return true
+	case *ClauseNode:
 	case *IfNode:
 	case *ListNode:
 		for _, node := range n.Nodes {
@@ -305,6 +375,8 @@ func (t *Tree) parse() {
 				newT := New("definition") // name will be updated once we know it.
 				newT.text = t.text
 				newT.Mode = t.Mode
+				newT.Source = t.Source
+				newT.marks = t.marks
 				newT.ParseName = t.ParseName
 				newT.startParse(t.funcs, t.lex, t.treeSet)
 				newT.parseDefinition()
@@ -411,6 +483,15 @@ func (t *Tree) action() (n Node) {
 		return t.templateControl()
 	case itemWith:
 		return t.withControl()
+	case itemIdentifier:
+		// 没有参数的{where}、{setblock}为子句块, where有参数时为函数
+		if _, ok := clauseKeyword[token.val]; ok {
+			if t.peek().typ == itemRightDelim {
+				return t.clauseControl(token)
+			}
+			t.backup2(token)
+			return t.newAction(token.pos, token.line, t.pipeline("command", itemRightDelim))
+		}
 	}
 	t.backup()
 	token := t.peek()
@@ -594,6 +675,27 @@ func (t *Tree) withControl() Node {
 	return t.newWith(t.parseControl("with"))
 }
 
+// clauseKeyword 子句块的名称和输出的sql关键字
+var clauseKeyword = map[string]string{
+	"where":    "where",
+	"setblock": "set",
+}
+
+// Clause:
+//
+//	{{where}} itemList {{end}}
+//	{{setblock}} itemList {{end}}
+//
+// Clause name is past.
+func (t *Tree) clauseControl(token item) Node {
+	t.expect(itemRightDelim, token.val)
+	list, next := t.itemList()
+	if next.Type() != nodeEnd {
+		t.errorf("expected end; found %s", next)
+	}
+	return t.newClause(token.pos, token.line, token.val, list)
+}
+
 // End:
 //
 //	{{end}}
@@ -637,6 +739,8 @@ func (t *Tree) blockControl() Node {
 	block := New(name) // name will be updated once we know it.
 	block.text = t.text
 	block.Mode = t.Mode
+	block.Source = t.Source
+	block.marks = t.marks
 	block.ParseName = t.ParseName
 	block.startParse(t.funcs, t.lex, t.treeSet)
 	var end Node
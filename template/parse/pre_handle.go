@@ -6,10 +6,11 @@ import (
 	"strings"
 )
 
-func newPreLex(input, left, right string) *sqlLexer {
+func newPreLex(input, left, right string, mode Mode) *sqlLexer {
 	return &sqlLexer{
 		input:     input,
 		leftDelim: left,
+		mode:      mode,
 	}
 }
 
@@ -21,15 +22,16 @@ func newLex(input, left, right string) *lexer {
 	}
 }
 
-func handleFiledName(input, left, right string, hasFunction func(name string) bool) (cond, body string, pos Pos) {
+func handleFiledName(input, left, right string, hasFunction func(name string) bool) (cond, body string, pos Pos, marks []srcMark) {
 	condSb := strings.Builder{}
 	bodySb := strings.Builder{}
 	l := newLex(input, left, right)
 	useMuiltiFieldOutput := true
 	useFunc := false
 	for l.nextItem().typ != itemEOF {
+		marks = append(marks, srcMark{out: Pos(bodySb.Len()), in: l.item.pos})
 		if l.item.typ == itemError {
-			panic(fmt.Errorf("template: %s:%d: %s", l.name, l.line, l.item.val))
+			panic(&preError{rest: len(input) - int(l.item.pos), msg: l.item.val})
 		} else if l.item.typ == itemRightDelim {
 			bodySb.WriteString(l.item.val)
 			break
@@ -39,7 +41,10 @@ func handleFiledName(input, left, right string, hasFunction func(name string) bo
 		if useMuiltiFieldOutput {
 			switch l.item.typ {
 			case itemIdentifier:
-				if !hasFunction(l.item.val) {
+				if _, ok := clauseKeyword[l.item.val]; ok && strings.HasPrefix(l.input[l.pos:], right) {
+					// {where}、{setblock}子句块
+					useMuiltiFieldOutput = false
+				} else if !hasFunction(l.item.val) {
 					condSb.WriteString(" ." + l.item.val)
 					bodySb.WriteRune('.')
 				} else {
@@ -56,7 +61,7 @@ func handleFiledName(input, left, right string, hasFunction func(name string) bo
 			case itemCharConstant:
 				_, _, tail, err := strconv.UnquoteChar(l.item.val[1:], l.item.val[0])
 				if err != nil {
-					return "", "", 0
+					return "", "", 0, nil
 				}
 				if tail != "'" {
 					l.item.val = fmt.Sprintf(`"%s"`, l.item.val[1:len(l.item.val)-1])
@@ -86,18 +91,34 @@ func handleFiledName(input, left, right string, hasFunction func(name string) bo
 	if !useMuiltiFieldOutput {
 		condSb.Reset()
 	}
-	return condSb.String(), bodySb.String(), l.pos
+	return condSb.String(), bodySb.String(), l.pos, marks
+}
+
+// addMarks 将子处理返回的marks按照输出和输入的起始位置偏移后追加
+func addMarks(marks, sub []srcMark, out, in Pos) []srcMark {
+	for _, m := range sub {
+		marks = append(marks, srcMark{out: out + m.out, in: in + m.in})
+	}
+	return marks
 }
 
-func handleOption(input string, left, right string, hasFunction func(name string) bool) (cond, body string, pos Pos) {
+// handleOption 处理[...]可选条件, input从[之后开始, 返回改写后的{if ...}...{end}.
+// [?(cond) then | else]使用显式的条件cond, |之后为不满足条件时输出的sql
+func handleOption(input string, left, right string, mode Mode, hasFunction func(name string) bool) (body string, pos Pos, marks []srcMark) {
 	condSb := strings.Builder{}
 	bodySb := strings.Builder{}
-	l := newPreLex(input, left, right)
-	leftParen := 0
+	l := newPreLex(input, left, right, mode)
+	expr, exprPos, exprEnd := optionExpr(input, mode)
+	hasExpr := exprEnd > 0
+	l.pos, l.start = exprEnd, exprEnd
+	hasElse := false
 	preKey := ""
+loop:
 	for l.nextItem().typ != itemEOF {
+		marks = append(marks, srcMark{out: Pos(bodySb.Len()), in: l.item.pos})
 		if l.item.typ == itemLeftDelim {
-			cond, body, pos := handleFiledName(l.input[l.item.pos:], left, right, hasFunction)
+			cond, body, pos, sub := handleFiledName(l.input[l.item.pos:], left, right, hasFunction)
+			marks = addMarks(marks, sub, Pos(bodySb.Len()), l.item.pos)
 			condSb.WriteString(cond)
 			bodySb.WriteString(body)
 			l.pos += pos
@@ -108,11 +129,26 @@ func handleOption(input string, left, right string, hasFunction func(name string
 		case itemChar:
 			switch l.item.val {
 			case "[":
-				leftParen++
+				body, pos, sub := handleOption(l.input[l.pos:], left, right, mode, hasFunction)
+				marks = addMarks(marks, sub, Pos(bodySb.Len()), l.pos)
+				bodySb.WriteString(body)
+				l.pos += pos
+				l.start += pos
 			case "]":
-				leftParen--
-				if leftParen == 0 {
-					return condSb.String(), bodySb.String(), l.pos
+				break loop
+			case "|":
+				// ||是字符串连接
+				if strings.HasPrefix(l.input[l.pos:], "|") {
+					l.pos++
+					l.start = l.pos
+					bodySb.WriteString("||")
+				} else if hasExpr && !hasElse {
+					hasElse = true
+					bodySb.WriteString(left)
+					bodySb.WriteString("else")
+					bodySb.WriteString(right)
+				} else {
+					bodySb.WriteString(l.item.val)
 				}
 			case "?":
 				condSb.WriteString(" ." + preKey)
@@ -124,7 +160,7 @@ func handleOption(input string, left, right string, hasFunction func(name string
 				bodySb.WriteString(l.item.val)
 			}
 		case itemField:
-			condSb.WriteString(l.item.val)
+			condSb.WriteString(" " + l.item.val)
 			bodySb.WriteString(left)
 			bodySb.WriteString(l.item.val)
 			bodySb.WriteString(right)
@@ -135,17 +171,101 @@ func handleOption(input string, left, right string, hasFunction func(name string
 			bodySb.WriteString(l.item.val)
 		}
 	}
-	return condSb.String(), bodySb.String(), l.pos
+	pos = l.pos
+	cond := strings.TrimSpace(condSb.String())
+	if hasExpr {
+		cond = expr
+	} else if cond != "" {
+		cond = "and " + cond
+	} else {
+		return bodySb.String(), pos, marks
+	}
+	prefix := left + "if "
+	outSb := strings.Builder{}
+	outSb.WriteString(prefix)
+	outSb.WriteString(cond)
+	outSb.WriteString(right)
+	var outMarks []srcMark
+	if hasExpr {
+		outMarks = append(outMarks, srcMark{out: Pos(len(prefix)), in: exprPos})
+	}
+	outMarks = addMarks(outMarks, marks, Pos(outSb.Len()), 0)
+	outSb.WriteString(bodySb.String())
+	outSb.WriteString(left)
+	outSb.WriteString("end")
+	outSb.WriteString(right)
+	return outSb.String(), pos, outMarks
+}
+
+// optionExpr 解析[?(cond) ...]中的条件, 返回条件、条件的起始位置和)之后的位置, 不是显式条件时end为0
+func optionExpr(input string, mode Mode) (expr string, start, end Pos) {
+	if mode&RawQuestion != 0 {
+		return "", 0, 0
+	}
+	i := len(input) - len(strings.TrimLeft(input, " \t\r\n"))
+	if !strings.HasPrefix(input[i:], "?(") {
+		return "", 0, 0
+	}
+	depth := 0
+	var quote byte
+	for j := i + 1; j < len(input); j++ {
+		c := input[j]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				j++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return input[i+2 : j], Pos(i + 2), Pos(j + 1)
+			}
+		}
+	}
+	return "", 0, 0
+}
+
+// preError 预处理中的错误, 各级处理的输入都是原始输入的后缀, rest为出错位置到输入结尾的长度, 由此换算出在原始输入中的位置
+type preError struct {
+	rest int
+	msg  string
+}
+
+func (e *preError) Error() string {
+	return e.msg
+}
+
+// srcMark 预处理后的位置out对应原始输入的位置in
+type srcMark struct {
+	out Pos
+	in  Pos
 }
 
 // 处理input中@信息，将其替换为left+filedName+right
-func handleAtsign(input, left, right string, hasFunction func(name string) bool) (body string) {
+func handleAtsign(input, left, right string, mode Mode, hasFunction func(name string) bool) (body string, marks []srcMark, err *preError) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*preError)
+			if !ok {
+				panic(r)
+			}
+			body, marks, err = "", nil, e
+		}
+	}()
 	bodySb := strings.Builder{}
-	l := newPreLex(input, left, right)
+	l := newPreLex(input, left, right, mode)
 	preKey := ""
 	for l.nextItem().typ != itemEOF {
+		marks = append(marks, srcMark{out: Pos(bodySb.Len()), in: l.item.pos})
 		if l.item.typ == itemLeftDelim {
-			_, body, pos := handleFiledName(l.input[l.pos:], left, right, hasFunction)
+			_, body, pos, sub := handleFiledName(l.input[l.pos:], left, right, hasFunction)
+			marks = addMarks(marks, sub, Pos(bodySb.Len()), l.pos)
 			bodySb.WriteString(body)
 			l.pos += pos
 			l.start += pos
@@ -156,12 +276,14 @@ func handleAtsign(input, left, right string, hasFunction func(name string) bool)
 			switch strings.ToLower(l.item.val) {
 			case "insert":
 				bodySb.WriteString(l.item.val)
-				columns, body, pos := handleInsertColumns(l.input[l.pos:], left, right, hasFunction)
+				columns, body, pos, sub := handleInsertColumns(l.input[l.pos:], left, right, mode, hasFunction)
+				marks = addMarks(marks, sub, Pos(bodySb.Len()), l.pos)
 				l.pos += pos
 				l.start += pos
 				bodySb.WriteString(body)
 				if len(columns) > 0 {
-					body, pos := handleInsertValues(l.input[l.pos:], left, right, hasFunction, columns)
+					body, pos, sub := handleInsertValues(l.input[l.pos:], left, right, mode, hasFunction, columns)
+					marks = addMarks(marks, sub, Pos(bodySb.Len()), l.pos)
 					l.pos += pos
 					l.start += pos
 					bodySb.WriteString(body)
@@ -181,22 +303,11 @@ func handleAtsign(input, left, right string, hasFunction func(name string) bool)
 				bodySb.WriteString(" ." + preKey)
 				bodySb.WriteString(right)
 			case "[":
-				cond, body, pos := handleOption(l.input[l.pos:], left, right, hasFunction)
+				body, pos, sub := handleOption(l.input[l.pos:], left, right, mode, hasFunction)
+				marks = addMarks(marks, sub, Pos(bodySb.Len()), l.pos)
 				l.pos += pos
 				l.start += pos
-				hasCond := strings.TrimSpace(cond) != ""
-				if hasCond {
-					bodySb.WriteString(left)
-					bodySb.WriteString("if and ")
-					bodySb.WriteString(cond)
-					bodySb.WriteString(right)
-				}
 				bodySb.WriteString(body)
-				if hasCond {
-					bodySb.WriteString(left)
-					bodySb.WriteString("end")
-					bodySb.WriteString(right)
-				}
 			default:
 				bodySb.WriteString(l.item.val)
 			}
@@ -206,5 +317,5 @@ func handleAtsign(input, left, right string, hasFunction func(name string) bool)
 			bodySb.WriteString(l.item.val)
 		}
 	}
-	return bodySb.String()
+	return bodySb.String(), marks, nil
 }
@@ -2,13 +2,15 @@ package parse
 
 import "strings"
 
-func handleInsertColumns(input, left, right string, hasFunction func(name string) bool) (columns []string, body string, pos Pos) {
+func handleInsertColumns(input, left, right string, mode Mode, hasFunction func(name string) bool) (columns []string, body string, pos Pos, marks []srcMark) {
 	bodySb := strings.Builder{}
-	l := newPreLex(input, left, right)
+	l := newPreLex(input, left, right, mode)
 	leftParen := false
 	for l.nextItem().typ != itemEOF {
+		marks = append(marks, srcMark{out: Pos(bodySb.Len()), in: l.item.pos})
 		if l.item.typ == itemLeftDelim {
-			_, body, pos := handleFiledName(l.input[l.pos:], left, right, hasFunction)
+			_, body, pos, sub := handleFiledName(l.input[l.pos:], left, right, hasFunction)
+			marks = addMarks(marks, sub, Pos(bodySb.Len()), l.pos)
 			bodySb.WriteString(body)
 			l.pos += pos
 			l.start += pos
@@ -19,7 +21,7 @@ func handleInsertColumns(input, left, right string, hasFunction func(name string
 			switch strings.ToLower(l.item.val) {
 			case "value", "values":
 				bodySb.WriteString(l.item.val)
-				return columns, bodySb.String(), l.pos
+				return columns, bodySb.String(), l.pos, marks
 			default:
 				bodySb.WriteString(l.item.val)
 			}
@@ -33,17 +35,19 @@ func handleInsertColumns(input, left, right string, hasFunction func(name string
 			bodySb.WriteString(l.item.val)
 		}
 	}
-	return columns, bodySb.String(), l.pos
+	return columns, bodySb.String(), l.pos, marks
 }
 
-func handleInsertValues(input, left, right string, hasFunction func(name string) bool, columns []string) (body string, pos Pos) {
+func handleInsertValues(input, left, right string, mode Mode, hasFunction func(name string) bool, columns []string) (body string, pos Pos, marks []srcMark) {
 	bodySb := strings.Builder{}
-	l := newPreLex(input, left, right)
+	l := newPreLex(input, left, right, mode)
 	i := 0
 	leftParen := 0
 	for l.nextItem().typ != itemEOF {
+		marks = append(marks, srcMark{out: Pos(bodySb.Len()), in: l.item.pos})
 		if l.item.typ == itemLeftDelim {
-			_, body, pos := handleFiledName(l.input[l.pos:], left, right, hasFunction)
+			_, body, pos, sub := handleFiledName(l.input[l.pos:], left, right, hasFunction)
+			marks = addMarks(marks, sub, Pos(bodySb.Len()), l.pos)
 			bodySb.WriteString(body)
 			l.pos += pos
 			l.start += pos
@@ -62,6 +66,8 @@ func handleInsertValues(input, left, right string, hasFunction func(name string)
 				bodySb.WriteByte('.')
 				bodySb.WriteString(columns[i])
 				bodySb.WriteString(right)
+			default:
+				bodySb.WriteString(l.item.val)
 			}
 		case itemField:
 			bodySb.WriteString(left)
@@ -74,11 +80,11 @@ func handleInsertValues(input, left, right string, hasFunction func(name string)
 			leftParen--
 			bodySb.WriteString(l.item.val)
 			if leftParen == 0 {
-				return bodySb.String(), l.pos
+				return bodySb.String(), l.pos, marks
 			}
 		default:
 			bodySb.WriteString(l.item.val)
 		}
 	}
-	return bodySb.String(), l.pos
+	return bodySb.String(), l.pos, marks
 }
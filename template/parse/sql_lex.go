@@ -13,6 +13,9 @@ type sqlLexer struct {
 	atEOF     bool   // we have hit the end of input and returned eof
 	item      item
 	leftDelim string // start of action marker
+	closer    string // 未结束的字符串、注释或$$的结束标记, 其中的模板动作处理完后继续扫描
+	closeTyp  itemType
+	mode      Mode // RawAtsign、RawQuestion、RawBracket时对应的符号原样输出
 }
 
 func (l *sqlLexer) next() rune {
@@ -68,6 +71,9 @@ func (l *sqlLexer) nextItem() item {
 }
 
 func lexSql(l *sqlLexer) stateSqlFn {
+	if l.closer != "" {
+		return lexSqlQuote
+	}
 	if strings.HasPrefix(l.input[l.pos:], l.leftDelim) {
 		return l.emit(itemLeftDelim)
 	}
@@ -80,20 +86,47 @@ func lexSql(l *sqlLexer) stateSqlFn {
 		}
 		return l.emit(itemSpace)
 	case r == '"':
-		for l.peek() == '"' {
-			l.next()
-		}
-		return l.emit(itemString)
+		return l.quote(`"`, itemString)
 	case r == '`':
-		for l.peek() == '`' {
+		return l.quote("`", itemRawString)
+	case r == '\'':
+		return l.quote("'", itemString)
+	case r == '-' && l.peek() == '-', r == '#':
+		// MySQL的#也是行注释
+		return l.quote("\n", itemComment)
+	case r == '/' && l.peek() == '*':
+		l.next()
+		return l.quote("*/", itemComment)
+	case r == ':' && l.peek() == ':':
+		// PostgreSQL类型转换::type, 类型名称不作为?的字段名
+		l.next()
+		for isAlphaNumeric(l.peek()) || l.peek() == '.' {
 			l.next()
 		}
-		return l.emit(itemRawString)
-	case r == '\'':
-		for l.peek() == '\'' {
+		if strings.HasPrefix(l.input[l.pos:], "[]") {
+			l.pos += 2
+		}
+		return l.emit(itemText)
+	case r == '$':
+		// PostgreSQL $$...$$或者$tag$...$tag$
+		if tag := dollarTag(l.input[l.start:]); tag != "" {
+			l.pos = l.start + Pos(len(tag))
+			return l.quote(tag, itemText)
+		}
+		return l.emit(itemChar)
+	case r == '\\':
+		// \@ \? \[ \] 输出符号本身
+		if c := l.peek(); c == '@' || c == '?' || c == '[' || c == ']' {
 			l.next()
+			i := item{typ: itemText, pos: l.start, val: string(c)}
+			l.start = l.pos
+			return l.emitItem(i)
 		}
-		return l.emit(itemString)
+		return l.emit(itemChar)
+	case r == '@' && l.mode&RawAtsign != 0,
+		r == '?' && l.mode&RawQuestion != 0,
+		(r == '[' || r == ']') && l.mode&RawBracket != 0:
+		return l.emit(itemText)
 	case r == '@':
 		// special look-ahead for ".field" so we don't break l.backup().
 		if l.pos < Pos(len(l.input)) {
@@ -109,11 +142,12 @@ func lexSql(l *sqlLexer) stateSqlFn {
 				}
 				if hasAt {
 					return l.emit(itemIdentifier)
-				} else {
+				} else if l.pos-l.start > 1 {
 					return l.emit(itemField)
 				}
 			}
 		}
+		return l.emit(itemChar)
 	case isAlphaNumeric(r):
 		for isAlphaNumeric(l.peek()) {
 			l.next()
@@ -128,5 +162,60 @@ func lexSql(l *sqlLexer) stateSqlFn {
 	default:
 		return l.emit(itemChar)
 	}
-	return nil
+}
+
+// quote 开始扫描字符串、带引号的标识符、注释或者$$, 直到closer结束
+func (l *sqlLexer) quote(closer string, typ itemType) stateSqlFn {
+	l.closer = closer
+	l.closeTyp = typ
+	return lexSqlQuote
+}
+
+// lexSqlQuote 扫描到结束标记为止, 其中不做@、?、[]的替换; 遇到模板动作时先输出已扫描的部分
+func lexSqlQuote(l *sqlLexer) stateSqlFn {
+	for {
+		if strings.HasPrefix(l.input[l.pos:], l.leftDelim) {
+			if l.pos > l.start {
+				return l.emit(l.closeTyp)
+			}
+			return l.emit(itemLeftDelim)
+		}
+		if strings.HasPrefix(l.input[l.pos:], l.closer) {
+			l.pos += Pos(len(l.closer))
+			// 字符串中连续两个引号表示引号本身
+			if len(l.closer) == 1 && l.closer != "\n" && strings.HasPrefix(l.input[l.pos:], l.closer) {
+				l.pos++
+				continue
+			}
+			l.closer = ""
+			return l.emit(l.closeTyp)
+		}
+		switch l.next() {
+		case eof:
+			l.closer = ""
+			if l.pos > l.start {
+				return l.emit(l.closeTyp)
+			}
+			return l.emit(itemEOF)
+		case '\\':
+			// MySQL字符串中的转义字符
+			if l.closeTyp == itemString && l.peek() != eof {
+				l.next()
+			}
+		}
+	}
+}
+
+// dollarTag 返回input开头的$$或者$tag$, 不是时返回空
+func dollarTag(input string) string {
+	for i := 1; i < len(input); i++ {
+		c := input[i]
+		if c == '$' {
+			return input[:i+1]
+		}
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 1 && '0' <= c && c <= '9') {
+			return ""
+		}
+	}
+	return ""
 }
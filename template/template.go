@@ -253,9 +253,14 @@ func (t *Template) Parse(text string) (*Template, error) {
 }
 
 func (t *Template) AddParse(name, text string) (*Template, error) {
+	return t.AddParseMode(name, text, 0, parse.Source{})
+}
+
+// AddParseMode 与AddParse相同, 使用mode解析模板, 例如parse.RawAtsign, 错误信息中的位置按照src换算到Go源文件
+func (t *Template) AddParseMode(name, text string, mode parse.Mode, src parse.Source) (*Template, error) {
 	t.init()
 	t.muFuncs.RLock()
-	trees, err := parse.Parse(name, text, t.leftDelim, t.rightDelim, t.parseFuncs, builtins())
+	trees, err := parse.ParseMode(name, text, t.leftDelim, t.rightDelim, mode, src, t.parseFuncs, builtins())
 	t.muFuncs.RUnlock()
 	if err != nil {
 		return nil, err
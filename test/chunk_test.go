@@ -0,0 +1,120 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+)
+
+type ChunkDB struct {
+	List  func(ctx context.Context, ids []int, state int) ([]*Test, error)
+	ByIds func(ctx context.Context, ids []int) ([]*Test, error)
+}
+
+const chunkDBSql = `package test
+
+type ChunkDB struct {
+	//sql?option{chunk_param:ids,chunk_size:2} select * from test where id in ({in .ids}) and state=@state
+	List func(ctx context.Context, ids []int, state int) ([]*Test, error)
+
+	//sql?option{chunk_param:ids,chunk_size:2} select * from test where id in ({in .ids})
+	ByIds func(ctx context.Context, ids []int) ([]*Test, error)
+}
+`
+
+func TestChunk(t *testing.T) {
+	sqldb, mock := tgsqltest.NewT(t)
+	tdb := tgsql.NewTgenSql(sqldb)
+	if err := tdb.LoadFuncDataInfoString(chunkDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &ChunkDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	items, err := tdb.RenderAll(context.Background(), dao.List, []int{1, 2, 3, 4, 5}, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || len(items[0].Args) != 3 || len(items[2].Args) != 2 {
+		t.Fatalf("unexpected chunks %+v", items)
+	}
+	mock.ExpectQuery(`select \* from test where id in`).WithArgs(1, 2, 9).
+		WillReturnRows(tgsqltest.NewRows("id", "name").AddRow(1, "a").AddRow(2, "b"))
+	mock.ExpectQuery(`select \* from test where id in`).WithArgs(3, 9).
+		WillReturnRows(tgsqltest.NewRows("id", "name").AddRow(3, "c"))
+	list, err := dao.List(context.Background(), []int{1, 2, 3}, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, v := range list {
+		names = append(names, v.Name)
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("unexpected result %v", names)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestChunkSingleParam(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(chunkDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &ChunkDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	// 只有一个切片参数时也按照chunk_param的名称引用
+	items, err := tdb.RenderAll(context.Background(), dao.ByIds, []int{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Sql != " select * from test where id in (? ,? )" || len(items[1].Args) != 1 {
+		t.Errorf("unexpected chunks %+v", items)
+	}
+}
+
+func TestChunkOption(t *testing.T) {
+	for _, sql := range []string{
+		"//sql?option{chunk_param:ids,chunk_size:0} select * from test where id in ({in .ids})",
+		"//sql?option{chunk_param:state} select * from test where state=@state",
+		"//sql?option{chunk_size:10} select * from test where id in ({in .ids})",
+	} {
+		tdb := tgsql.NewTgenSql(nil)
+		src := strings.Replace(chunkDBSql, "//sql?option{chunk_param:ids,chunk_size:2} select * from test where id in ({in .ids}) and state=@state", sql, 1)
+		if err := tdb.LoadFuncDataInfoString(src); err != nil {
+			t.Fatal(err)
+		}
+		if err := tgsql.InitDBFunc(tdb, &ChunkDB{}); err == nil {
+			t.Errorf("%s: expected error", sql)
+		}
+	}
+}
+
+type ChunkMultiDB struct {
+	Columns func(ctx context.Context, ids []int) ([]int64, []string, error)
+}
+
+func TestChunkMultiResult(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	err := tdb.LoadFuncDataInfoString(`package test
+
+type ChunkMultiDB struct {
+	//sql?option{chunk_param:ids} select id, name from test where id in ({in .ids})
+	Columns func(ctx context.Context, ids []int) ([]int64, []string, error)
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tgsql.InitDBFunc(tdb, &ChunkMultiDB{}); err == nil || !strings.Contains(err.Error(), "one slice and error") {
+		t.Errorf("err = %v, want chunk_param multi result error", err)
+	}
+}
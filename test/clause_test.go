@@ -0,0 +1,53 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+type ClauseDB struct {
+	Where  func(ctx context.Context, id int, name string) ([]*Test, error)
+	Set    func(ctx context.Context, id int, name string, state int) error
+	Range  func(ctx context.Context, ids []int, name string) ([]*Test, error)
+	Filter func(ctx context.Context, m map[string]any, name string) ([]*Test, error)
+}
+
+const clauseDBSql = `package test
+
+type ClauseDB struct {
+	//sql select * from test {where}[and id=@id] [AND name=@name]{end}order by id
+	Where func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql update test {setblock}[name=@name,] [state=@state,]{end}where id=@id
+	Set func(ctx context.Context, id int, name string, state int) error
+
+	//sql select * from test {where}{range .ids} or id={.}{end} [or name=@name]{end}
+	Range func(ctx context.Context, ids []int, name string) ([]*Test, error)
+
+	//sql select * from test where {where .m} [and name=@name]
+	Filter func(ctx context.Context, m map[string]any, name string) ([]*Test, error)
+}
+`
+
+func TestClauseBlock(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(clauseDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &ClauseDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	checkRender(t, tdb, []renderCase{
+		{"Where", dao.Where, " select * from test where id=?  AND name=? order by id", []any{int64(1), "a"}, []any{1, "a"}},
+		{"Where", dao.Where, " select * from test where name=? order by id", []any{"a"}, []any{0, "a"}},
+		{"Where", dao.Where, " select * from test order by id", []any{}, []any{0, ""}},
+		{"Set", dao.Set, " update test set name=? , state=? where id=? ", []any{"a", int64(2), int64(1)}, []any{1, "a", 2}},
+		{"Set", dao.Set, " update test set state=? where id=? ", []any{int64(2), int64(1)}, []any{1, "", 2}},
+		{"Range", dao.Range, " select * from test where id=?  or id=?  or name=? ", []any{int64(1), int64(2), "a"}, []any{[]int{1, 2}, "a"}},
+		{"Range", dao.Range, " select * from test ", []any{}, []any{[]int{}, ""}},
+		{"Filter", dao.Filter, " select * from test where id = ? and name=? ", []any{int64(1), "a"}, []any{map[string]any{"id": 1}, "a"}},
+	})
+}
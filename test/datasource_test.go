@@ -0,0 +1,143 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+)
+
+type DataSourceDB struct {
+	Get      func(ctx context.Context, id int) (*Test, error)
+	GetOrder func(ctx context.Context, id int) (*Test, error)
+}
+
+type OrderSourceDB struct {
+	_    tgsql.DataSource `db:"orders"`
+	Find func(ctx context.Context, id int) (*Test, error)
+}
+
+const dataSourceDBSql = `package test
+
+type DataSourceDB struct {
+	//sql select * from test where id=@id
+	Get func(ctx context.Context, id int) (*Test, error)
+
+	//sql?option{db:orders} select * from orders where id=@id
+	GetOrder func(ctx context.Context, id int) (*Test, error)
+}
+
+type OrderSourceDB struct {
+	_ tgsql.DataSource ` + "`db:\"orders\"`" + `
+
+	//sql select * from orders_by_struct where id=@id
+	Find func(ctx context.Context, id int) (*Test, error)
+}
+`
+
+func newDataSourceDB(t *testing.T) (*tgsql.TgenSql, *tgsqltest.Mock, *tgsqltest.Mock) {
+	db, mock := tgsqltest.NewT(t)
+	orders, ordersMock := tgsqltest.NewT(t)
+	tdb := tgsql.NewTgenSql(db)
+	tdb.AddDB("orders", orders)
+	if err := tdb.LoadFuncDataInfoString(dataSourceDBSql); err != nil {
+		t.Fatal(err)
+	}
+	return tdb, mock, ordersMock
+}
+
+func TestDataSource(t *testing.T) {
+	tdb, mock, ordersMock := newDataSourceDB(t)
+	dao, orderDao := &DataSourceDB{}, &OrderSourceDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgsql.InitDBFunc(tdb, orderDao); err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(`select \* from test`).WithArgs(1)
+	ordersMock.ExpectQuery(`select \* from orders where`).WithArgs(2)
+	ordersMock.ExpectQuery(`select \* from orders_by_struct`).WithArgs(3)
+	ordersMock.ExpectQuery(`select \* from test`).WithArgs(4)
+	ctx := context.Background()
+	for _, call := range []func() error{
+		func() error { _, err := dao.Get(ctx, 1); return err },
+		func() error { _, err := dao.GetOrder(ctx, 2); return err },
+		func() error { _, err := orderDao.Find(ctx, 3); return err },
+		// 上下文中的数据源优先
+		func() error { _, err := dao.Get(tgsql.NewDataSource(ctx, "orders"), 4); return err },
+	} {
+		if err := call(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := dao.Get(tgsql.NewDataSource(ctx, "missing"), 5); err == nil {
+		t.Error("expected unregistered data source error")
+	}
+	if err := errors.Join(mock.ExpectationsWereMet(), ordersMock.ExpectationsWereMet()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDataSourceTx(t *testing.T) {
+	tdb, mock, ordersMock := newDataSourceDB(t)
+	dao := &DataSourceDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`select \* from test`).WithArgs(1)
+	mock.ExpectRollback()
+	ordersMock.ExpectBegin()
+	ordersMock.ExpectQuery(`select \* from orders`).WithArgs(2)
+	ordersMock.ExpectCommit()
+
+	ctx, err := tdb.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dao.Get(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	// 默认数据源的事务中不能调用orders数据源的方法
+	if _, err := dao.GetOrder(ctx, 2); !errors.Is(err, tgsql.ErrTxDataSource) {
+		t.Errorf("GetOrder in default tx: err = %v, want ErrTxDataSource", err)
+	}
+	if err := tdb.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err = tdb.Begin(tgsql.NewDataSource(context.Background(), "orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dao.GetOrder(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := tdb.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := errors.Join(mock.ExpectationsWereMet(), ordersMock.ExpectationsWereMet()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddDBConcurrent(t *testing.T) {
+	tdb, _, _ := newDataSourceDB(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			db, _ := tdb.GetDB("orders")
+			tdb.AddDB(fmt.Sprintf("db_%d", i), db)
+			tdb.RangeDB(func(string, *sql.DB) bool { return true })
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+)
+
+type PgRow struct {
+	Id   int64
+	Ids  []*int64
+	Tags []string
+}
+
+type PgDB struct {
+	ListAny  func(ctx context.Context, ids []int64, name string) ([]*PgRow, error)
+	ListIn   func(ctx context.Context, ids []int64) ([]*PgRow, error)
+	ListName func(ctx context.Context, names []string) ([]*PgRow, error)
+}
+
+const pgDBSql = `package test
+
+type PgDB struct {
+	//sql select * from pg_row where id {any .ids} and name=@name
+	ListAny func(ctx context.Context, ids []int64, name string) ([]*PgRow, error)
+
+	//sql select * from pg_row where id in ({in .})
+	ListIn func(ctx context.Context, ids []int64) ([]*PgRow, error)
+
+	//sql select * from pg_row where name in ({in .})
+	ListName func(ctx context.Context, names []string) ([]*PgRow, error)
+}
+`
+
+func TestPostgresDialect(t *testing.T) {
+	db, mock := tgsqltest.NewT(t)
+	tdb := tgsql.NewTgenSql(db)
+	tdb.SetDialect(tgsql.DialectPostgres)
+	if err := tdb.LoadFuncDataInfoString(pgDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &PgDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	sql, args, err := tdb.Render(ctx, dao.ListAny, []int64{1, 2}, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := " select * from pg_row where id = ANY($1 )  and name=$2 "; sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+	if want := []any{"{1,2}", "a"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %#v, want %#v", args, want)
+	}
+
+	mock.ExpectQuery(`in \(select unnest\(CAST\(\$1 AS bigint\[\]\)\) \)`).WithArgs("{3}").
+		WillReturnRows(tgsqltest.NewRows("id", "ids", "tags").ColumnTypes("INT8", "_INT8", "_TEXT").
+			AddRow(3, "{1,NULL}", `{"a b",c}`))
+	list, err := dao.ListIn(ctx, []int64{3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := int64(1)
+	want := PgRow{Id: 3, Ids: []*int64{&one, nil}, Tags: []string{"a b", "c"}}
+	if len(list) != 1 || !reflect.DeepEqual(*list[0], want) {
+		t.Errorf("list = %+v, want %+v", list, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDialectAfterInit(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(pgDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &PgDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	tdb.SetDialect(tgsql.DialectPostgres)
+	checkRender(t, tdb, []renderCase{
+		{"ListAny", dao.ListAny, " select * from pg_row where id = ANY($1 )  and name=$2 ", []any{"{1,2}", "a"}, []any{[]int64{1, 2}, "a"}},
+		// unnest的参数需要明确的数组类型
+		{"ListIn", dao.ListIn, " select * from pg_row where id in (select unnest(CAST($1 AS bigint[])) )", []any{"{3}"}, []any{[]int64{3}}},
+		{"ListName", dao.ListName, " select * from pg_row where name in (select unnest(CAST($1 AS text[])) )", []any{`{"a"}`}, []any{[]string{"a"}}},
+	})
+}
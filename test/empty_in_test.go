@@ -0,0 +1,68 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+type EmptyInDB struct {
+	List  func(ctx context.Context, ids []int, state int) ([]*Test, error)
+	Any   func(ctx context.Context, ids []int, state int) ([]*Test, error)
+	Error func(ctx context.Context, ids []int, state int) ([]*Test, error)
+	Skip  func(ctx context.Context, ids []int, state int) ([]*Test, error)
+}
+
+const emptyInDBSql = `package test
+
+type EmptyInDB struct {
+	//sql select * from test where id in ({in .ids}) and state=@state
+	List func(ctx context.Context, ids []int, state int) ([]*Test, error)
+
+	//sql select * from test where id {any .ids} and state=@state
+	Any func(ctx context.Context, ids []int, state int) ([]*Test, error)
+
+	//sql?option{empty_in:error} select * from test where id in ({in .ids})
+	Error func(ctx context.Context, ids []int, state int) ([]*Test, error)
+
+	//sql?option{empty_in:skip} select * from test where id in ({in .ids})
+	Skip func(ctx context.Context, ids []int, state int) ([]*Test, error)
+}
+`
+
+func TestEmptyIn(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(emptyInDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &EmptyInDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	checkRender(t, tdb, []renderCase{
+		{"List", dao.List, " select * from test where id in (NULL) and state=? ", []any{int64(1)}, []any{[]int{}, 1}},
+		{"List", dao.List, " select * from test where id in (? ,? ) and state=? ", []any{int64(1), int64(2), int64(1)}, []any{[]int{1, 2}, 1}},
+		{"Any", dao.Any, " select * from test where id in (NULL)  and state=? ", []any{int64(1)}, []any{[]int(nil), 1}},
+	})
+	ctx := context.Background()
+	if _, _, err := tdb.Render(ctx, dao.Error, []int{}, 1); !errors.Is(err, tgsql.ErrEmptyIn) {
+		t.Errorf("Error: err = %v, want ErrEmptyIn", err)
+	}
+	items, err := tdb.RenderAll(ctx, dao.Skip, []int{}, 1)
+	if err != nil || len(items) != 0 {
+		t.Errorf("Skip: items = %v, err = %v, want no sql", items, err)
+	}
+	if list, err := dao.Skip(ctx, nil, 1); list != nil || err != nil {
+		t.Errorf("Skip: list = %v, err = %v, want empty result", list, err)
+	}
+	// 上下文设置优先于方法选项和全局设置
+	tdb.SetEmptyIn(tgsql.EmptyInError)
+	if _, _, err := tdb.Render(ctx, dao.List, []int{}, 1); !errors.Is(err, tgsql.ErrEmptyIn) {
+		t.Errorf("List: err = %v, want ErrEmptyIn", err)
+	}
+	if _, _, err := tdb.Render(tgsql.NewEmptyIn(ctx, tgsql.EmptyInNull), dao.Error, []int{}, 1); err != nil {
+		t.Errorf("Error: err = %v, want nil", err)
+	}
+}
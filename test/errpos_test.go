@@ -0,0 +1,43 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+type ErrParseDB struct {
+	//sql select * from test where id=@id and name={.name)}
+	Find func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+
+type ErrExecDB struct {
+	/*sql
+	select * from test
+	where id=@id
+	  and name={.Nmae} [and id = @id]
+	*/
+	List func(ctx context.Context, testInfo *Test) ([]*Test, error)
+}
+
+func TestErrorPosition(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfo(testDbSql); err != nil {
+		t.Fatal(err)
+	}
+	err := tgsql.InitDBFunc(tdb, &ErrParseDB{})
+	if err == nil || !strings.Contains(err.Error(), "errpos_test.go:12:55: ") || !strings.Contains(err.Error(), "right paren") {
+		t.Errorf("parse err = %v, want errpos_test.go:12:55", err)
+	}
+
+	dao := &ErrExecDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = tdb.Render(context.Background(), dao.List, &Test{Id: 1})
+	if err == nil || !strings.Contains(err.Error(), "errpos_test.go:20:14: ") || !strings.Contains(err.Error(), "Nmae") {
+		t.Errorf("exec err = %v, want errpos_test.go:20:14", err)
+	}
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+func TestExpand(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	tests := []struct {
+		sql  string
+		text string
+		tree string
+	}{
+		{
+			"select * from test where id=@id and name {like .name} [and age > ?]",
+			"select * from test where id={@id} and name {like .name} {if and .age}and age > {.age}{end}",
+			`1:1 text "select * from test where id="
+1:29 action .id
+1:32 text " and name "
+1:43 action like .name
+1:54 text " "
+1:55 if and .age
+  1:56 text "and age > "
+  1:66 action .age
+`,
+		},
+		{
+			"insert into test (id, name) values (?, ?)",
+			"insert into test (id, name) values ({.id}, {.name})",
+			`1:1 text "insert into test (id, name) values ("
+1:37 action .id
+1:38 text ", "
+1:40 action .name
+1:41 text ")"
+`,
+		},
+	}
+	for _, tt := range tests {
+		e, err := tdb.Expand(tt.sql)
+		if err != nil {
+			t.Errorf("%s: %v", tt.sql, err)
+			continue
+		}
+		if e.Text != tt.text {
+			t.Errorf("text = %q, want %q", e.Text, tt.text)
+		}
+		if e.Tree != tt.tree {
+			t.Errorf("tree = \n%s, want\n%s", e.Tree, tt.tree)
+		}
+	}
+	if _, err := tdb.Expand("select {.id)}"); err == nil {
+		t.Error("want parse error")
+	}
+}
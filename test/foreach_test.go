@@ -0,0 +1,77 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+type ForeachDB struct {
+	Tuple  func(ctx context.Context, list []*Test, state int) ([]*Test, error)
+	Values func(ctx context.Context, list []map[string]any, state int) error
+	Case   func(ctx context.Context, list []Test, state int) error
+	Ids    func(ctx context.Context, ids []int, state int) ([]*Test, error)
+}
+
+const foreachDBSql = `package test
+
+type ForeachDB struct {
+	//sql select * from test where (id, name) in {foreach .list "(" ", " ")" "" "Id" "Name"}
+	Tuple func(ctx context.Context, list []*Test, state int) ([]*Test, error)
+
+	//sql insert into test(id, name, state) values {foreach .list "" ", " "" "(?, ?, 0)" "id" "name"}
+	Values func(ctx context.Context, list []map[string]any, state int) error
+
+	//sql update test set name = case id {foreach .list "" " " "" "when ? then ?" "id" "name"} end, state=@state
+	Case func(ctx context.Context, list []Test, state int) error
+
+	//sql select * from test where id in {foreach .ids "(" "," ")" "?"}
+	Ids func(ctx context.Context, ids []int, state int) ([]*Test, error)
+}
+`
+
+func TestForeach(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(foreachDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &ForeachDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	list := []*Test{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}}
+	maps := []map[string]any{{"id": 1, "name": "a"}, {"id": 2, "name": "b"}}
+	checkRender(t, tdb, []renderCase{
+		{"Tuple", dao.Tuple, " select * from test where (id, name) in ((?, ?), (?, ?))", []any{int64(1), "a", int64(2), "b"}, []any{list, 0}},
+		{"Values", dao.Values, " insert into test(id, name, state) values (?, ?, 0), (?, ?, 0)", []any{int64(1), "a", int64(2), "b"}, []any{maps, 0}},
+		{"Case", dao.Case, " update test set name = case id when ? then ? when ? then ? end, state=? ", []any{int64(1), "a", int64(2), "b", int64(3)}, []any{[]Test{*list[0], *list[1]}, 3}},
+		{"Ids", dao.Ids, " select * from test where id in (?,?)", []any{int64(1), int64(2)}, []any{[]int{1, 2}, 0}},
+	})
+}
+
+func TestForeachEmpty(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(foreachDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &ForeachDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	checkRender(t, tdb, []renderCase{
+		{"Ids", dao.Ids, " select * from test where id in (NULL)", []any{}, []any{[]int{}, 0}},
+		// 元组的列数与item一致, 否则MySQL报错Operand should contain 2 column(s)
+		{"Tuple", dao.Tuple, " select * from test where (id, name) in ((NULL, NULL))", []any{}, []any{[]*Test{}, 0}},
+	})
+	// 空列表按照EmptyIn设置处理
+	ctx := tgsql.NewEmptyIn(context.Background(), tgsql.EmptyInError)
+	if _, _, err := tdb.Render(ctx, dao.Values, []map[string]any(nil), 0); !errors.Is(err, tgsql.ErrEmptyIn) {
+		t.Errorf("Values: err = %v, want ErrEmptyIn", err)
+	}
+	ctx = tgsql.NewEmptyIn(context.Background(), tgsql.EmptyInSkip)
+	if items, err := tdb.RenderAll(ctx, dao.Tuple, []*Test{}, 0); err != nil || len(items) != 0 {
+		t.Errorf("Tuple: items = %v, err = %v, want no sql", items, err)
+	}
+}
@@ -0,0 +1,40 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/sqlval"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+)
+
+func TestGoldenTestDB(t *testing.T) {
+	sqlval.RegisterScanVal(&IdScan{})
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfo(testDbSql); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewTestDB(tdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test := &Test{Id: 1, Name: "a"}
+	test2 := &Test2{Id: 2, Name: "b", Extend: Test{Id: 3, Name: "c"}}
+	tgsqltest.Golden(t, tdb, db, tgsqltest.Fixtures{
+		"Insert":                 {test},
+		"Select":                 {1},
+		"Select2":                {2},
+		"Select2COne":            {test2},
+		"SelectNoReturnErr":      {1},
+		"SelectOne":              {1},
+		"SelectOneNoReturnErr":   {1},
+		"SelectByTestInfo":       {test},
+		"SelectAtSignByTestInfo": {test},
+		"Insert2":                {test2},
+		"Insert3":                {test2},
+		"InsertNotResultId":      {test},
+		"BatchInsert":            {[]*Test{test, {Id: 2, Name: "b"}}},
+		"Update":                 {test},
+		"UpdateNotResultId":      {test},
+	})
+}
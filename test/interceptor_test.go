@@ -0,0 +1,56 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+)
+
+func TestInterceptorConvertArgs(t *testing.T) {
+	tdb, db, mock := newMockTestDB(t)
+	tdb.Use(func(ctx context.Context, info *tgsql.StmtInfo, next tgsql.Next) error {
+		// 拦截器改写的参数在执行前转换
+		info.Args = []any{map[string]int{"id": 3}}
+		return next(ctx)
+	})
+	mock.ExpectQuery(`select \* from test2 where id=\?`).WithArgs(`{"id":3}`).
+		WillReturnRows(tgsqltest.NewRows("id", "name"))
+	if _, err := db.Select2(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInterceptorBatch(t *testing.T) {
+	tdb, db, mock := newMockTestDB(t)
+	var infos []tgsql.StmtInfo
+	tdb.Use(func(ctx context.Context, info *tgsql.StmtInfo, next tgsql.Next) error {
+		if info.Action == tgsql.StmtBatch && info.Sql == "" {
+			t.Error("batch sql is empty before next")
+		}
+		err := next(ctx)
+		infos = append(infos, *info)
+		return err
+	})
+	mock.ExpectExec(`insert into test values`).WithArgs(1, "a").WillReturnResult(0, 1)
+	mock.ExpectExec(`insert into test values`).WithArgs(2, "b").WillReturnResult(0, 1)
+	if err := db.BatchInsert(context.Background(), []*Test{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	for _, info := range infos[:len(infos)-1] {
+		if !info.InBatch {
+			t.Errorf("%s in batch: InBatch = false", info.Action)
+		}
+	}
+	batch := infos[len(infos)-1]
+	if batch.Action != tgsql.StmtBatch || batch.InBatch || batch.BatchRows != 2 || batch.RowsAffected != 2 || batch.Rows != 0 {
+		t.Errorf("batch info = %+v", batch)
+	}
+}
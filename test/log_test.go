@@ -0,0 +1,77 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+)
+
+type logItem struct {
+	action tgsql.StmtAction
+	slow   string
+}
+
+type testLogger struct {
+	items []logItem
+}
+
+func (l *testLogger) Log(ctx context.Context, info *tgsql.StmtInfo) {
+	l.items = append(l.items, logItem{action: info.Action})
+}
+
+func (l *testLogger) Slow(ctx context.Context, info *tgsql.StmtInfo, sql string) {
+	l.items[len(l.items)-1].slow = sql
+}
+
+type LogDB struct {
+	Find func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+
+const logDBSql = `package test
+
+type LogDB struct {
+	//sql select * from test where note = 'what?' and id=@id and name=@name
+	Find func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+`
+
+func TestSlowLogInterpolate(t *testing.T) {
+	sqldb, mock := tgsqltest.NewT(t)
+	tdb := tgsql.NewTgenSql(sqldb)
+	tdb.SetDialect(tgsql.DialectPostgres)
+	if err := tdb.LoadFuncDataInfoString(logDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &LogDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	logger := &testLogger{}
+	tdb.SetSqlLogger(logger)
+	tdb.SetSlowThreshold(time.Nanosecond)
+	mock.ExpectQuery(`select * from test where note = 'what?' and id=$1 and name=$2`).Exact().WithArgs(1, "a")
+	if _, err := dao.Find(context.Background(), 1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	want := " select * from test where note = 'what?' and id=1  and name='a' "
+	if len(logger.items) != 1 || logger.items[0].slow != want {
+		t.Errorf("logs = %+v, want slow sql %q", logger.items, want)
+	}
+}
+
+func TestLogBatchOnce(t *testing.T) {
+	tdb, db, mock := newMockTestDB(t)
+	logger := &testLogger{}
+	tdb.SetSqlLogger(logger)
+	mock.ExpectExec(`insert into test values`).WithArgs(1, "a")
+	mock.ExpectExec(`insert into test values`).WithArgs(2, "b")
+	if err := db.BatchInsert(context.Background(), []*Test{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(logger.items) != 1 || logger.items[0].action != tgsql.StmtBatch {
+		t.Errorf("logs = %+v, want one batch log", logger.items)
+	}
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+)
+
+func newMockTestDB(t *testing.T) (*tgsql.TgenSql, *TestDB, *tgsqltest.Mock) {
+	sqldb, mock := tgsqltest.NewT(t)
+	tdb := tgsql.NewTgenSql(sqldb)
+	if err := tdb.LoadFuncDataInfo(testDbSql); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewTestDB(tdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tdb, db, mock
+}
+
+func TestMockSelectJson(t *testing.T) {
+	_, db, mock := newMockTestDB(t)
+	mock.ExpectQuery(`select \* from test2 where id=\?`).WithArgs(2).
+		WillReturnRows(tgsqltest.NewRows("id", "name", "extend").ColumnTypes("INT", "VARCHAR", "JSON").
+			AddRow(2, "b", `{"id":3,"name":"c"}`))
+	list, err := db.Select2(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "b" || list[0].Extend.Id != 3 || list[0].Extend.Name != "c" {
+		t.Errorf("unexpected result %+v", list)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMockBatchInsertTx(t *testing.T) {
+	tdb, db, mock := newMockTestDB(t)
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`insert into test values`)
+	mock.ExpectExec(`insert into test values`).WithArgs(1, "a").WillReturnResult(0, 1)
+	mock.ExpectExec(`insert into test values`).WithArgs(2, "b").WillReturnResult(0, 1)
+	mock.ExpectCommit()
+	err := func() (err error) {
+		ctx, err := tdb.Begin(context.Background())
+		if err != nil {
+			return err
+		}
+		defer tdb.AutoCommit(ctx, &err)
+		return db.BatchInsert(ctx, []*Test{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}})
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMockUnmetExpectation(t *testing.T) {
+	_, db, mock := newMockTestDB(t)
+	// not_prepare把参数插值到sql中
+	mock.ExpectExec(`update test set name='b' where id=2`).Exact().WithArgs()
+	mock.ExpectQuery(`select`)
+	if err := db.UpdateNotResultId(context.Background(), &Test{Id: 2, Name: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err == nil {
+		t.Error("expected unmet select expectation")
+	}
+}
+
+func TestMockInvalidRegexp(t *testing.T) {
+	_, db, mock := newMockTestDB(t)
+	mock.ExpectQuery(`select * from test2 where id=(?`).WithArgs(2)
+	_, err := db.Select2(context.Background(), 2)
+	if err == nil || !strings.Contains(err.Error(), "is not a valid regexp") {
+		t.Errorf("err = %v, want invalid regexp error", err)
+	}
+}
+
+func TestMockClose(t *testing.T) {
+	sqldb, mock, err := tgsqltest.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqldb.Close()
+	mock.Close()
+	if err := sqldb.Ping(); err == nil || !strings.Contains(err.Error(), "unknown dsn") {
+		t.Errorf("err = %v, want unknown dsn", err)
+	}
+}
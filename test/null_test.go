@@ -0,0 +1,67 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+type NullFilter struct {
+	Name  *string
+	State *int
+}
+
+type NullDB struct {
+	Delete    func(ctx context.Context, id int, deletedAt *time.Time) error
+	Set       func(ctx context.Context, m map[string]any, id int) error
+	Value     func(ctx context.Context, m map[string]any, id int) error
+	WhereNull func(ctx context.Context, f *NullFilter, id int) ([]*Test, error)
+	SetNull   func(ctx context.Context, f *NullFilter, id int) error
+}
+
+const nullDBSql = `package test
+
+type NullDB struct {
+	//sql update test set deleted_at = @deletedAt where id=@id
+	Delete func(ctx context.Context, id int, deletedAt *time.Time) error
+
+	//sql update test set {set .m} where id=@id
+	Set func(ctx context.Context, m map[string]any, id int) error
+
+	//sql update test set name = {.m.name} where id=@id
+	Value func(ctx context.Context, m map[string]any, id int) error
+
+	//sql select * from test where {wherenull .f} and id=@id
+	WhereNull func(ctx context.Context, f *NullFilter, id int) ([]*Test, error)
+
+	//sql update test set {setnull .f} where id=@id
+	SetNull func(ctx context.Context, f *NullFilter, id int) error
+}
+`
+
+func TestNullParam(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(nullDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &NullDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	state := 1
+	checkRender(t, tdb, []renderCase{
+		{"Delete", dao.Delete, " update test set deleted_at = ?  where id=? ", []any{nil, int64(1)}, []any{1, (*time.Time)(nil)}},
+		{"Set", dao.Set, " update test set name = ? where id=? ", []any{nil, int64(1)}, []any{map[string]any{"name": nil}, 1}},
+		{"Value", dao.Value, " update test set name = ?  where id=? ", []any{nil, int64(1)}, []any{map[string]any{"name": nil}, 1}},
+		{"WhereNull", dao.WhereNull, " select * from test where Name IS NULL and State = ? and id=? ", []any{int64(1), int64(1)}, []any{&NullFilter{State: &state}, 1}},
+		{"SetNull", dao.SetNull, " update test set Name = NULL,State = ? where id=? ", []any{int64(1), int64(1)}, []any{&NullFilter{State: &state}, 1}},
+	})
+	// 不存在的key可能是拼写错误, 不绑定NULL
+	_, _, err := tdb.Render(context.Background(), dao.Value, map[string]any{"nmae": "a"}, 1)
+	if err == nil || !strings.Contains(err.Error(), `map has no entry for key "name"`) {
+		t.Errorf("Value: err = %v, want missing key error", err)
+	}
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+type OptionDB struct {
+	Multi  func(ctx context.Context, id int, name string, state int) ([]*Test, error)
+	Nested func(ctx context.Context, id int, name string, state int) ([]*Test, error)
+	Expr   func(ctx context.Context, id int, name string, state int) ([]*Test, error)
+	Concat func(ctx context.Context, id int, name string, state int) ([]*Test, error)
+}
+
+const optionDBSql = `package test
+
+type OptionDB struct {
+	//sql select * from test where 1=1 [and id=@id] [and name=@name] order by id
+	Multi func(ctx context.Context, id int, name string, state int) ([]*Test, error)
+
+	//sql select * from test where 1=1 [and id=@id [and name=?]]
+	Nested func(ctx context.Context, id int, name string, state int) ([]*Test, error)
+
+	//sql select * from test where id=@id [?(ne .state -1) and state=@state | and state <> 9]
+	Expr func(ctx context.Context, id int, name string, state int) ([]*Test, error)
+
+	//sql select * from test where 1=1 [and name = 'x' || @name]
+	Concat func(ctx context.Context, id int, name string, state int) ([]*Test, error)
+}
+`
+
+func TestOptionBlock(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(optionDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &OptionDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	checkRender(t, tdb, []renderCase{
+		{"Multi", dao.Multi, " select * from test where 1=1 and id=?   order by id", []any{int64(1)}, []any{1, "", 0}},
+		{"Multi", dao.Multi, " select * from test where 1=1 and id=?  and name=?  order by id", []any{int64(1), "a"}, []any{1, "a", 0}},
+		{"Nested", dao.Nested, " select * from test where 1=1 and id=?  ", []any{int64(1)}, []any{1, "", 0}},
+		{"Nested", dao.Nested, " select * from test where 1=1 and id=?  and name=? ", []any{int64(1), "a"}, []any{1, "a", 0}},
+		{"Nested", dao.Nested, " select * from test where 1=1 ", []any{}, []any{0, "a", 0}},
+		{"Expr", dao.Expr, " select * from test where id=?   and state=?  ", []any{int64(1), int64(0)}, []any{1, "a", 0}},
+		{"Expr", dao.Expr, " select * from test where id=?   and state <> 9", []any{int64(1)}, []any{1, "a", -1}},
+		{"Concat", dao.Concat, " select * from test where 1=1 and name = 'x' || ? ", []any{"a"}, []any{1, "a", 0}},
+	})
+}
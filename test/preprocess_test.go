@@ -0,0 +1,214 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+type renderCase struct {
+	name string
+	fn   any
+	sql  string
+	args []any
+	in   []any // 方法参数, 为空时使用(1, "a")
+}
+
+// checkRender 渲染每个方法并比较sql和参数
+func checkRender(t *testing.T, tdb *tgsql.TgenSql, tests []renderCase) {
+	t.Helper()
+	for _, tt := range tests {
+		in := tt.in
+		if in == nil {
+			in = []any{1, "a"}
+		}
+		sql, args, err := tdb.Render(context.Background(), tt.fn, in...)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if sql != tt.sql {
+			t.Errorf("%s: sql = %q, want %q", tt.name, sql, tt.sql)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: args = %v, want %v", tt.name, args, tt.args)
+		}
+	}
+}
+
+type LiteralDB struct {
+	Quote   func(ctx context.Context, id int, name string) ([]*Test, error)
+	Comment func(ctx context.Context, id int, name string) ([]*Test, error)
+	Hash    func(ctx context.Context, id int, name string) ([]*Test, error)
+	Block   func(ctx context.Context, id int, name string) ([]*Test, error)
+	Cast    func(ctx context.Context, id int, name string) ([]*Test, error)
+	Dollar  func(ctx context.Context, id int, name string) ([]*Test, error)
+	Ident   func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+
+const literalDBSql = `package test
+
+type LiteralDB struct {
+	//sql select * from test where note = 'what? a@b.com [x]' and name='it''s @name' and id=@id
+	Quote func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	/*sql
+	select * from test -- who? @name [x]
+	where id=@id
+	*/
+	Comment func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql select * from test where id=@id # what? @name [x]
+	Hash func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql select * from test /* @name? */ where id=@id
+	Block func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql select * from test where id=@id::int8 [and name::text = ?]
+	Cast func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql select $fn$ select @x, '?' $fn$ as body, $$a[1]$$ from test where id=@id
+	Dollar func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql select "a@b", ` + "`c?`" + ` from test where id=@id
+	Ident func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+`
+
+func TestPreprocessLiteral(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(literalDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &LiteralDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	tests := []renderCase{
+		{"Quote", dao.Quote, " select * from test where note = 'what? a@b.com [x]' and name='it''s @name' and id=? ", []any{int64(1)}, nil},
+		{"Comment", dao.Comment, " select * from test -- who? @name [x]\n where id=?  ", []any{int64(1)}, nil},
+		{"Hash", dao.Hash, " select * from test where id=?  # what? @name [x]", []any{int64(1)}, nil},
+		{"Block", dao.Block, " select * from test /* @name? */ where id=? ", []any{int64(1)}, nil},
+		{"Cast", dao.Cast, " select * from test where id=? ::int8 and name::text = ? ", []any{int64(1), "a"}, nil},
+		{"Dollar", dao.Dollar, " select $fn$ select @x, '?' $fn$ as body, $$a[1]$$ from test where id=? ", []any{int64(1)}, nil},
+		{"Ident", dao.Ident, " select \"a@b\", `c?` from test where id=? ", []any{int64(1)}, nil},
+	}
+	checkRender(t, tdb, tests)
+}
+
+type RawSymbolDB struct {
+	RowNum     func(ctx context.Context, id int, name string) ([]*Test, error)
+	RowNumRaw  func(ctx context.Context, id int, name string) ([]*Test, error)
+	JsonPath   func(ctx context.Context, id int, name string) ([]*Test, error)
+	JsonbExist func(ctx context.Context, id int, name string) ([]*Test, error)
+	JsonbRaw   func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+
+const rawSymbolDBSql = `package test
+
+type RawSymbolDB struct {
+	//sql select \@rownum := \@rownum + 1 as rn, t.* from test t, (select \@rownum := 0) r where id=@id
+	RowNum func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql?option{raw_symbols:@} select @rownum := @rownum + 1 as rn from test where id={.id} [and name=?]
+	RowNumRaw func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql select data->'$[0]', data->>'$.a' from test where id=@id
+	JsonPath func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql select * from test where data \? 'a' and data \?| array\['b','c'\] and tags\[1\] = @name
+	JsonbExist func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql?option{raw_symbols:?[]} select * from test where data ?| array['b'] and tags[1] = @name
+	JsonbRaw func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+`
+
+func TestPreprocessRawSymbols(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(rawSymbolDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &RawSymbolDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	tests := []renderCase{
+		{"RowNum", dao.RowNum, " select @rownum := @rownum + 1 as rn, t.* from test t, (select @rownum := 0) r where id=? ", []any{int64(1)}, nil},
+		{"RowNumRaw", dao.RowNumRaw, " select @rownum := @rownum + 1 as rn from test where id=?  and name=? ", []any{int64(1), "a"}, nil},
+		{"JsonPath", dao.JsonPath, " select data->'$[0]', data->>'$.a' from test where id=? ", []any{int64(1)}, nil},
+		{"JsonbExist", dao.JsonbExist, " select * from test where data ? 'a' and data ?| array['b','c'] and tags[1] = ? ", []any{"a"}, nil},
+		{"JsonbRaw", dao.JsonbRaw, " select * from test where data ?| array['b'] and tags[1] = ? ", []any{"a"}, nil},
+	}
+	checkRender(t, tdb, tests)
+
+	tdb = tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(strings.Replace(rawSymbolDBSql, "raw_symbols:@", "raw_symbols:#", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgsql.InitDBFunc(tdb, &RawSymbolDB{}); err == nil || !strings.Contains(err.Error(), "RawSymbolDB.RowNumRaw") {
+		t.Errorf("err = %v, want raw_symbols error", err)
+	}
+}
+
+type NotPrepareDB struct {
+	Quote func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+
+const notPrepareDBSql = `package test
+
+type NotPrepareDB struct {
+	//sql?option{not_prepare:true} select * from test where note = 'what?' and id=@id -- who?
+	Quote func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+`
+
+func TestPreprocessNotPrepare(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(notPrepareDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &NotPrepareDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	tests := []renderCase{
+		{"Quote", dao.Quote, " select * from test where note = 'what?' and id=1  -- who?", []any{}, nil},
+	}
+	checkRender(t, tdb, tests)
+}
+
+type NotPrepareRawDB struct {
+	Escape func(ctx context.Context, id int, name string) ([]*Test, error)
+	Raw    func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+
+const notPrepareRawDBSql = `package test
+
+type NotPrepareRawDB struct {
+	//sql?option{not_prepare:true} select * from test where data \? 'a' and name = @name
+	Escape func(ctx context.Context, id int, name string) ([]*Test, error)
+
+	//sql?option{not_prepare:true,raw_symbols:?[]} select * from test where data ?| array['b'] and id = {.id}
+	Raw func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+`
+
+func TestPreprocessNotPrepareRaw(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfoString(notPrepareRawDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &NotPrepareRawDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	tests := []renderCase{
+		{"Escape", dao.Escape, " select * from test where data ? 'a' and name = 'a' ", []any{}, nil},
+		{"Raw", dao.Raw, " select * from test where data ?| array['b'] and id = 1 ", []any{}, nil},
+	}
+	checkRender(t, tdb, tests)
+}
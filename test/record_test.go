@@ -0,0 +1,38 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+func TestRecordSqlConcurrent(t *testing.T) {
+	tdb, db, _ := newMockTestDB(t)
+	ctx := tdb.NewRecordSql(tgsql.NewDryRun(context.Background()))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if _, err := db.Select2(ctx, id); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	recordSql, _ := tdb.FromRecordSql(ctx)
+	// 执行中读取记录
+	_ = recordSql.Items()
+	wg.Wait()
+	items := recordSql.Items()
+	if len(items) != 8 {
+		t.Fatalf("items = %d, want 8", len(items))
+	}
+	for _, item := range items {
+		if !strings.HasSuffix(item.DaoType, "TestDB") || item.FuncName != "Select2" {
+			t.Errorf("item = %s.%s, want TestDB.Select2", item.DaoType, item.FuncName)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+func TestRenderDryRun(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	if err := tdb.LoadFuncDataInfo(testDbSql); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewTestDB(tdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, args, err := tdb.Render(context.Background(), db.SelectByTestInfo, &Test{Id: 1, Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := " select * from test where id=?  and name=?  "; sql != want {
+		t.Errorf("sql = %q, want %q", sql, want)
+	}
+	if want := []any{int64(1), "a"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
+
+func TestSqlTemplateDryRun(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	tdb.SetDryRun(true)
+	ctx := tdb.NewRecordSql(context.Background())
+	list, err := tgsql.SqlTemplate[[]*Test]{Ctx: ctx, Sql: "select * from test where id=@id", Param: map[string]any{"id": 1}}.Query(tdb)
+	if err != nil || len(list) != 0 {
+		t.Fatalf("Query = %v, %v", list, err)
+	}
+	ret, err := tgsql.SqlTemplate[any]{Ctx: ctx, Sql: "delete from test where id=@id", Param: map[string]any{"id": 1}}.Exec(tdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := ret.RowsAffected(); n != 0 {
+		t.Errorf("RowsAffected = %d, want 0", n)
+	}
+	recordSql, _ := tdb.FromRecordSql(ctx)
+	if items := recordSql.Items(); len(items) != 2 {
+		t.Errorf("items = %d, want 2", len(items))
+	}
+}
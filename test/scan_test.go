@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/sqlval"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+)
+
+type ScanDB struct {
+	Lenient func(ctx context.Context) ([]*Test, error)
+	Strict  func(ctx context.Context) ([]Test, error)
+	Std     func(ctx context.Context) ([]*Test, error)
+}
+
+const scanDBSql = `package test
+
+type ScanDB struct {
+	//sql?option{scan:lenient} select id,name from test
+	Lenient func(ctx context.Context) ([]*Test, error)
+
+	//sql?option{scan:strict} select * from test
+	Strict func(ctx context.Context) ([]Test, error)
+
+	//sql?option{scan:default} select id,name from test
+	Std func(ctx context.Context) ([]*Test, error)
+}
+`
+
+func TestScanMode(t *testing.T) {
+	db, mock := tgsqltest.NewT(t)
+	tdb := tgsql.NewTgenSql(db)
+	if err := tdb.LoadFuncDataInfoString(scanDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &ScanDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	mock.ExpectQuery("select id,name from test").
+		WillReturnRows(tgsqltest.NewRows("id", "name").AddRow(1, nil).AddRow(nil, "b"))
+	list, err := dao.Lenient(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || *list[0] != (Test{Id: 1}) || *list[1] != (Test{Name: "b"}) {
+		t.Errorf("lenient scan = %+v, %+v", list[0], list[1])
+	}
+
+	mock.ExpectQuery("select \\* from test").
+		WillReturnRows(tgsqltest.NewRows("id", "name").AddRow(1, nil))
+	_, err = dao.Strict(ctx)
+	if err == nil || !strings.Contains(err.Error(), "ScanDB.Strict") || !strings.Contains(err.Error(), "column name is NULL, can't scan into test.Test.Name") {
+		t.Errorf("strict null error = %v", err)
+	}
+
+	mock.ExpectQuery("select \\* from test").
+		WillReturnRows(tgsqltest.NewRows("id", "name", "age").AddRow(1, "a", 20))
+	_, err = dao.Strict(ctx)
+	if err == nil || !strings.Contains(err.Error(), "column age has no destination field in test.Test") {
+		t.Errorf("strict column error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScanModeOverride(t *testing.T) {
+	db, mock := tgsqltest.NewT(t)
+	tdb := tgsql.NewTgenSql(db)
+	tdb.SetScanMode(sqlval.ScanLenient)
+	if err := tdb.LoadFuncDataInfoString(scanDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &ScanDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	// scan:default覆盖全局的lenient
+	mock.ExpectQuery("select id,name from test").
+		WillReturnRows(tgsqltest.NewRows("id", "name").AddRow(1, nil))
+	if _, err := dao.Std(context.Background()); err == nil {
+		t.Error("scan:default with NULL name: expected error")
+	}
+}
+
+// rawBytes 命名的字节切片类型, 与sql.RawBytes一样直接扫描原始值
+type rawBytes []byte
+
+type JsonRow struct {
+	Id     int32
+	Tags   []string
+	Extend *Test
+	Label  string `db:",json"`
+	Raw    rawBytes
+	Doc    rawBytes
+}
+
+type JsonDB struct {
+	List func(ctx context.Context) ([]*JsonRow, error)
+}
+
+const jsonDBSql = `package test
+
+type JsonDB struct {
+	//sql select * from json_row
+	List func(ctx context.Context) ([]*JsonRow, error)
+}
+`
+
+func TestScanJson(t *testing.T) {
+	db, mock := tgsqltest.NewT(t)
+	tdb := tgsql.NewTgenSql(db)
+	if err := tdb.LoadFuncDataInfoString(jsonDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &JsonDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery("select \\* from json_row").
+		WillReturnRows(tgsqltest.NewRows("id", "tags", "extend", "label", "raw", "doc").
+			ColumnTypes("INT", "TEXT", "JSONB", "VARCHAR", "TEXT", "JSONB").
+			AddRow(1, `["a","b"]`, `{"id":2,"name":"c"}`, `"x"`, []byte(`{"a":1}`), []byte(`[1]`)))
+	// 扫描时注册json类型
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sqlval.RegisterJsonDatabaseType("json_text")
+	}()
+	list, err := dao.List(context.Background())
+	<-done
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := JsonRow{Id: 1, Tags: []string{"a", "b"}, Extend: &Test{Id: 2, Name: "c"}, Label: "x", Raw: rawBytes(`{"a":1}`), Doc: rawBytes(`[1]`)}
+	if len(list) != 1 || !reflect.DeepEqual(*list[0], want) {
+		t.Errorf("list = %+v, want %+v", list, want)
+	}
+}
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+)
+
+type ShardDB struct {
+	Get    func(ctx context.Context, id int) (*Test, error)
+	List   func(ctx context.Context, name string) ([]*Test, error)
+	Delete func(ctx context.Context, name string) error
+	Insert func(ctx context.Context, list []*Test) error
+}
+
+const shardDBSql = `package test
+
+type ShardDB struct {
+	//sql?option{shard:user} select u.* from user u where u.name <> 'user' and id=@id -- from user
+	Get func(ctx context.Context, id int) (*Test, error)
+
+	//sql?option{shard:user} select * from ` + "`user`" + ` where name=@name
+	List func(ctx context.Context, name string) ([]*Test, error)
+
+	//sql?option{shard:user} delete from user where name=@name
+	Delete func(ctx context.Context, name string) error
+
+	//sql?option{shard:user,batch_insert:true} insert into user(id, name) values(@id, @name)
+	Insert func(ctx context.Context, list []*Test) error
+}
+`
+
+func newShardDB(t *testing.T) (*tgsql.TgenSql, *ShardDB, []*tgsqltest.Mock) {
+	tdb := tgsql.NewTgenSql(nil)
+	var mocks []*tgsqltest.Mock
+	for _, name := range []string{"user_00", "user_01"} {
+		db, mock := tgsqltest.NewT(t)
+		tdb.AddDB(name, db)
+		mocks = append(mocks, mock)
+	}
+	if err := tdb.AddShardRule("user", &tgsql.ShardRule{
+		Param:  "id",
+		Shards: []string{"user_00", "user_01"},
+		Table:  "user",
+		FanOut: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tdb.LoadFuncDataInfoString(shardDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &ShardDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	return tdb, dao, mocks
+}
+
+func TestShardRoute(t *testing.T) {
+	_, dao, mocks := newShardDB(t)
+	// 只改写from后的表名, 字符串、注释和别名列不改写
+	mocks[1].ExpectQuery(`select u.* from user_01 u where u.name <> 'user' and id=? -- from user`).Exact().WithArgs(3).
+		WillReturnRows(tgsqltest.NewRows("id", "name").AddRow(3, "c"))
+	mocks[0].ExpectQuery("select * from `user_00` where name=?").Exact().WithArgs("a").
+		WillReturnRows(tgsqltest.NewRows("id", "name").AddRow(2, "a"))
+	mocks[1].ExpectQuery("select * from `user_01` where name=?").Exact().WithArgs("a").
+		WillReturnRows(tgsqltest.NewRows("id", "name").AddRow(1, "a"))
+	ctx := context.Background()
+	if v, err := dao.Get(ctx, 3); err != nil || v == nil || v.Name != "c" {
+		t.Fatalf("Get = %v, %v", v, err)
+	}
+	// 没有分片键的查询在所有分片上执行并合并结果
+	list, err := dao.List(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Id != 2 || list[1].Id != 1 {
+		t.Errorf("List = %v", list)
+	}
+	if err := dao.Delete(ctx, "a"); !errors.Is(err, tgsql.ErrShardKeyMissing) {
+		t.Errorf("Delete: err = %v, want ErrShardKeyMissing", err)
+	}
+	for _, m := range mocks {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestShardBatchInsert(t *testing.T) {
+	_, dao, mocks := newShardDB(t)
+	mocks[1].ExpectExec(`insert into user_01`).WithArgs(1, "a")
+	mocks[1].ExpectExec(`insert into user_01`).WithArgs(3, "c")
+	mocks[0].ExpectExec(`insert into user_00`).WithArgs(2, "b")
+	err := dao.Insert(context.Background(), []*Test{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}, {Id: 3, Name: "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range mocks {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestShardTx(t *testing.T) {
+	tdb, dao, mocks := newShardDB(t)
+	mocks[1].ExpectBegin()
+	mocks[1].ExpectQuery(`from user_01`).WithArgs(1)
+	mocks[1].ExpectRollback()
+	ctx, err := tdb.Begin(tgsql.NewDataSource(context.Background(), "user_01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dao.Get(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	// 分片不是事务的数据源
+	if _, err := dao.Get(ctx, 2); !errors.Is(err, tgsql.ErrTxDataSource) {
+		t.Errorf("Get(2): err = %v, want ErrTxDataSource", err)
+	}
+	if _, err := dao.List(ctx, "a"); !errors.Is(err, tgsql.ErrTxDataSource) {
+		t.Errorf("List: err = %v, want ErrTxDataSource", err)
+	}
+	if err := tdb.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range mocks {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+type ShardMultiDB struct {
+	ListCount func(ctx context.Context, name string) ([]*Test, int, error)
+}
+
+func TestShardFanOutMultiResult(t *testing.T) {
+	tdb, _, _ := newShardDB(t)
+	err := tdb.LoadFuncDataInfoString(`package test
+
+type ShardMultiDB struct {
+	//sql?option{shard:user} select * from user where name=@name
+	ListCount func(ctx context.Context, name string) ([]*Test, int, error)
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每个分片的结果会覆盖上一个分片的其他返回值
+	err = tgsql.InitDBFunc(tdb, &ShardMultiDB{})
+	if err == nil || !strings.Contains(err.Error(), "fan out only support select func returning one slice and error") {
+		t.Errorf("InitDBFunc: err = %v, want fan out error", err)
+	}
+}
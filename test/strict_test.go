@@ -0,0 +1,75 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+type StrictDB struct {
+	Select func(ctx context.Context, testInfo *Test) ([]*Test, error)
+}
+
+type StrictListDB struct {
+	List func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+
+const strictDBSql = `package test
+
+type StrictDB struct {
+	/*sql
+	select * from test
+	where id=@id
+	  and name=@nmae
+	*/
+	Select func(ctx context.Context, testInfo *Test) ([]*Test, error)
+}
+
+type StrictListDB struct {
+	/*sql?option{strict:true}
+	select * from test where id=@id [and name=@title]
+	*/
+	List func(ctx context.Context, id int, name string) ([]*Test, error)
+}
+`
+
+func TestStrictTestDB(t *testing.T) {
+	tdb := tgsql.NewTgenSql(nil)
+	tdb.SetStrict(true)
+	if err := tdb.LoadFuncDataInfo(testDbSql); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTestDB(tdb); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStrictUnknownField(t *testing.T) {
+	tests := []struct {
+		dao    any
+		strict bool
+		want   []string
+	}{
+		{&StrictDB{}, true, []string{"StrictDB.Select", "Select:4:", "nmae", "test.Test"}},
+		{&StrictListDB{}, false, []string{"StrictListDB.List", "List:2:", "title", "params: id, name"}},
+	}
+	for _, tt := range tests {
+		tdb := tgsql.NewTgenSql(nil)
+		tdb.SetStrict(tt.strict)
+		if err := tdb.LoadFuncDataInfoString(strictDBSql); err != nil {
+			t.Fatal(err)
+		}
+		err := tgsql.InitDBFunc(tdb, tt.dao)
+		if err == nil {
+			t.Errorf("%T: expected strict check error", tt.dao)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain %q", err, want)
+			}
+		}
+	}
+}
@@ -72,6 +72,11 @@ type TestDB struct {
 	*/
 	InsertNotResultId func(ctx context.Context, testInfo *Test) error
 
+	/*sql?option{batch_insert:true}
+	insert into test values(@id,@name)
+	*/
+	BatchInsert func(ctx context.Context, list []*Test) error
+
 	// 需要返回新插入的受影响id
 	/*sql
 	update test
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+)
+
+type VerifyUser struct {
+	Id   int32
+	Name string `db:",required"`
+	Age  int32
+}
+
+type VerifyDB struct {
+	List  func(ctx context.Context, name string) ([]*VerifyUser, error)
+	Count func(ctx context.Context) (int64, error)
+	Top   func(ctx context.Context) ([]*VerifyUser, error)
+}
+
+const verifyDBSql = `package test
+
+type VerifyDB struct {
+	//sql select id,nick,age from user where name like @name
+	List func(ctx context.Context, name string) ([]*VerifyUser, error)
+
+	//sql select count(*) from user
+	Count func(ctx context.Context) (int64, error)
+
+	//sql select id, name, age from user order by id limit 10
+	Top func(ctx context.Context) ([]*VerifyUser, error)
+}
+`
+
+func TestVerifyColumns(t *testing.T) {
+	db, mock := tgsqltest.NewT(t)
+	tdb := tgsql.NewTgenSql(db)
+	if err := tdb.LoadFuncDataInfoString(verifyDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &VerifyDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery("select id,nick,age from user where name like ? LIMIT 0").Exact().
+		WithArgs("").
+		WillReturnRows(tgsqltest.NewRows("id", "nick", "age").ColumnTypes("INT", "VARCHAR", "VARCHAR"))
+	mock.ExpectQuery("select count(*) from user LIMIT 0").Exact().
+		WillReturnRows(tgsqltest.NewRows("count(*)").ColumnTypes("BIGINT"))
+	// 已经有limit时包装成子查询
+	mock.ExpectQuery("SELECT * FROM (select id, name, age from user order by id limit 10) tgsql_verify LIMIT 0").Exact().
+		WillReturnRows(tgsqltest.NewRows("id", "name", "age").ColumnTypes("INT", "VARCHAR", "INT"))
+	err := tdb.VerifyColumns(context.Background(), dao)
+	if err == nil {
+		t.Fatal("expected verify error")
+	}
+	for _, want := range []string{
+		"VerifyDB.List column nick has no destination field in test.VerifyUser",
+		"VerifyDB.List column age VARCHAR can't scan into test.VerifyUser.Age int32",
+		"VerifyDB.List required field test.VerifyUser.Name has no column",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "VerifyDB.Count") || strings.Contains(err.Error(), "VerifyDB.Top") {
+		t.Errorf("unexpected Count or Top error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestVerifyColumnsShard(t *testing.T) {
+	tdb, dao, mocks := newShardDB(t)
+	// 分片方法在渲染使用的分片上检查, 不使用默认数据源
+	mocks[0].ExpectQuery("select u.* from user_00 u where u.name <> 'user' and id=? -- from user\nLIMIT 0").Exact().
+		WithArgs(0).
+		WillReturnRows(tgsqltest.NewRows("id", "name").ColumnTypes("INT", "VARCHAR"))
+	mocks[0].ExpectQuery("select * from `user_00` where name=?\nLIMIT 0").Exact().
+		WithArgs("").
+		WillReturnRows(tgsqltest.NewRows("id", "name").ColumnTypes("INT", "VARCHAR"))
+	if err := tdb.VerifyColumns(context.Background(), dao); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range mocks {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+}
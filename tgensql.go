@@ -3,26 +3,39 @@ package tgsql
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"embed"
 	"errors"
 	"fmt"
 	"runtime"
+	"sync"
+	"time"
 
 	"github.com/tianxinzizhen/tgsql/load"
 	"github.com/tianxinzizhen/tgsql/sqlval"
 	"github.com/tianxinzizhen/tgsql/sqlwrite"
 	"github.com/tianxinzizhen/tgsql/template"
-	"github.com/tianxinzizhen/tgsql/util"
 )
 
 type TgenSql struct {
 	db                      *sql.DB
+	dbs                     map[string]*sql.DB
+	dbsMu                   sync.RWMutex
+	shardRules              map[string]*ShardRule
 	localFuncDataInfo       *load.LoadFuncDataInfo
 	leftDelim, rightDelim   string
 	sqlLogFunc              func(ctx context.Context, funcName, sql string, args ...any)
 	filedName               template.FiledName
 	sqlFunc                 template.FuncMap
 	template                map[uintptr]map[int]*template.Template
+	interceptors            []Interceptor
+	sqlLogger               SqlLogger
+	dryRun                  bool
+	strict                  bool
+	scanMode                sqlval.ScanMode
+	dialect                 Dialect
+	emptyIn                 EmptyIn
+	slowThreshold           time.Duration
 	SqlEscapeBytesBackslash bool
 }
 
@@ -30,6 +43,11 @@ func (tdb *TgenSql) SetSqlEscapeBytesBackslash(sqlEscapeBytesBackslash bool) {
 	tdb.SqlEscapeBytesBackslash = sqlEscapeBytesBackslash
 }
 
+// SetScanMode 设置查询结果中NULL值和多余列的处理方式, 单个方法可以使用?option{scan:strict}或者?option{scan:lenient}覆盖
+func (tdb *TgenSql) SetScanMode(mode sqlval.ScanMode) {
+	tdb.scanMode = mode
+}
+
 func (tdb *TgenSql) Delims(leftDelim, rightDelim string) {
 	tdb.leftDelim = leftDelim
 	tdb.rightDelim = rightDelim
@@ -72,6 +90,8 @@ func NewTgenSql(sqlDB *sql.DB) *TgenSql {
 	for k, v := range sqlFunc {
 		tdb.sqlFunc[k] = v
 	}
+	tdb.sqlFunc["in"] = tdb.dialectIn
+	tdb.sqlFunc["any"] = tdb.dialectAny
 	return tdb
 }
 
@@ -87,17 +107,23 @@ func (tdb *TgenSql) templateBuild(templateSql *template.Template, op *funcExecOp
 	if err != nil {
 		return err
 	}
+	if err = tdb.checkEmptyIn(op, sqlWrite); err != nil {
+		return err
+	}
 	if op.option&optionNotPrepare != 0 {
-		op.sql, err = util.InterpolateParams(sqlWrite.Sql(), sqlWrite.Args(), tdb.SqlEscapeBytesBackslash)
+		op.sql, err = tdb.interpolate(sqlWrite, sqlWrite.Args())
 		if err != nil {
 			return err
 		}
 		op.args = nil
 	} else {
-		op.sql = sqlWrite.Sql()
-		op.args = sqlWrite.Args()
+		op.sql, op.args = tdb.rebind(sqlWrite), sqlWrite.Args()
+	}
+	if op.shardTable != "" {
+		op.sql = rewriteShardTable(op.sql, op.shardTable, op.shardSuffix)
 	}
-	tdb.sqlPrintAndRecord(op.ctx, templateSql.Name(), op.sql, op.args)
+	op.sqlWrite, op.renderSql = sqlWrite, op.sql
+	tdb.sqlPrint(op.ctx, templateSql.Name(), op.sql, op.args)
 	return err
 }
 func (tdb *TgenSql) query(op *funcExecOption) error {
@@ -112,77 +138,96 @@ func (tdb *TgenSql) queryOption(op *funcExecOption, queryOption queryOption) err
 	if op.ctx == nil {
 		op.ctx = context.Background()
 	}
-	db := op.GetDB(op.ctx).(sqlDB)
-	var err error
-	op.args, err = sqlval.ConvertValues(op.db, op.args)
-	if err != nil {
-		return err
-	}
-	rows, err := db.QueryContext(op.ctx, op.sql, op.args...)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-	columns, err := rows.ColumnTypes()
-	if err != nil {
-		return err
-	}
-	for rows.Next() {
-		dest, df, err := sqlval.GetScanDest(tdb.filedName, columns, op.result)
+	return tdb.intercept(op, StmtQuery, func(info *StmtInfo) error {
+		db := op.GetDB(op.ctx).(sqlDB)
+		rows, err := db.QueryContext(op.ctx, op.sql, op.args...)
 		if err != nil {
 			return err
 		}
-		err = rows.Scan(dest...)
+		defer rows.Close()
+		columns, err := rows.ColumnTypes()
 		if err != nil {
 			return err
 		}
-		for _, fn := range df {
-			fn()
+		scanOption := sqlval.ScanOption{FiledName: tdb.filedName, Mode: tdb.scanMode}
+		if op.scanMode != sqlval.ScanDefault {
+			scanOption.Mode = op.scanMode
 		}
-		if queryOption.selectOne {
-			break
+		for rows.Next() {
+			dest, afterScan, err := sqlval.GetScanDestOption(scanOption, columns, op.result)
+			if err != nil {
+				return err
+			}
+			err = rows.Scan(dest...)
+			if err != nil {
+				return err
+			}
+			if err = afterScan(); err != nil {
+				return err
+			}
+			info.Rows++
+			if queryOption.selectOne {
+				break
+			}
 		}
-	}
-	return nil
+		return rows.Err()
+	})
 }
 
 func (tdb *TgenSql) exec(op *funcExecOption) (ret sql.Result, err error) {
 	if op.ctx == nil {
 		op.ctx = context.Background()
 	}
-	switch db := op.GetDB(op.ctx).(type) {
-	case sqlDB:
-		op.args, err = sqlval.ConvertValues(op.db, op.args)
-		if err != nil {
-			return nil, err
+	err = tdb.intercept(op, StmtExec, func(info *StmtInfo) error {
+		switch db := op.GetDB(op.ctx).(type) {
+		case sqlDB:
+			ret, err = db.ExecContext(op.ctx, op.sql, op.args...)
+		case sqlStmt:
+			ret, err = db.ExecContext(op.ctx, op.args...)
+		default:
+			return errors.New("db not support exec")
 		}
-		result, err := db.ExecContext(op.ctx, op.sql, op.args...)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		return result, nil
-	case sqlStmt:
-		result, err := db.ExecContext(op.ctx, op.args...)
-		if err != nil {
-			return nil, err
+		info.Result = ret
+		if n, err := ret.RowsAffected(); err == nil {
+			info.RowsAffected = n
 		}
-		return result, nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		// dry-run或拦截器没有执行语句
+		ret = driver.RowsAffected(0)
 	}
-	return nil, errors.New("db not support exec")
+	return ret, nil
 }
 
 func (tdb *TgenSql) prepareContext(op *funcExecOption) (ret *sql.Stmt, err error) {
 	if op.ctx == nil {
 		op.ctx = context.Background()
 	}
-	if db, ok := op.GetDB(op.ctx).(sqlPrepare); ok {
-		result, err := db.PrepareContext(op.ctx, op.sql)
-		if err != nil {
-			return nil, err
+	err = tdb.intercept(op, StmtPrepare, func(info *StmtInfo) error {
+		db, ok := op.GetDB(op.ctx).(sqlPrepare)
+		if !ok {
+			return errors.New("db not support prepare")
 		}
-		return result, nil
+		ret, err = db.PrepareContext(op.ctx, op.sql)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("db not support prepare")
+	return ret, nil
+}
+
+// Expand 返回sql经过@、[]、?和insert简写改写后的模板文本和解析树摘要, 用于调试简写语法
+func (tdb *TgenSql) Expand(sql string) (*template.Expansion, error) {
+	return template.New("").Delims(tdb.leftDelim, tdb.rightDelim).
+		Funcs(tdb.sqlFunc).Expand(sql)
 }
 
 func (tdb *TgenSql) ParseSql(tsql string) (*template.Template, error) {
@@ -191,7 +236,7 @@ func (tdb *TgenSql) ParseSql(tsql string) (*template.Template, error) {
 		Funcs(tdb.sqlFunc).Parse(tsql)
 }
 
-func (tdb *TgenSql) sqlTemplateBuild(ctx context.Context, tsql string, parms any) (string, []any, error) {
+func (tdb *TgenSql) sqlTemplateBuild(op *funcExecOption) error {
 	pc, _, line, _ := runtime.Caller(2)
 	if tdb.template == nil {
 		tdb.template = make(map[uintptr]map[int]*template.Template)
@@ -200,28 +245,31 @@ func (tdb *TgenSql) sqlTemplateBuild(ctx context.Context, tsql string, parms any
 		tdb.template[pc] = make(map[int]*template.Template)
 	}
 	if _, ok := tdb.template[pc][line]; !ok {
-		templateSql, err := tdb.ParseSql(tsql)
+		templateSql, err := tdb.ParseSql(op.sql)
 		if err != nil {
-			return "", nil, err
+			return err
 		}
 		tdb.template[pc][line] = templateSql
 	}
 	templateSql := tdb.template[pc][line]
 	sqw := &sqlwrite.SqlWrite{}
-	err := templateSql.Execute(sqw, parms)
+	err := templateSql.Execute(sqw, op.param)
 	if err != nil {
-		return "", nil, err
+		return err
 	}
-	tdb.sqlPrintAndRecord(ctx, fmt.Sprintf("%s:%d", runtime.FuncForPC(pc).Name(), line), sqw.Sql(), sqw.Args())
-	return sqw.Sql(), sqw.Args(), err
+	if err = tdb.checkEmptyIn(op, sqw); err != nil {
+		return err
+	}
+	op.funcName = fmt.Sprintf("%s:%d", runtime.FuncForPC(pc).Name(), line)
+	op.sql, op.args = tdb.rebind(sqw), sqw.Args()
+	op.sqlWrite, op.renderSql = sqw, op.sql
+	tdb.sqlPrint(op.ctx, op.funcName, op.sql, op.args)
+	return nil
 }
 
-func (tdb *TgenSql) sqlPrintAndRecord(ctx context.Context, funcName, sql string, args []any) {
+func (tdb *TgenSql) sqlPrint(ctx context.Context, funcName, sql string, args []any) {
 	if tdb.sqlLogFunc == nil {
 		return
 	}
 	tdb.sqlLogFunc(ctx, funcName, sql, args...)
-	if recordSql, ok := tdb.FromRecordSql(ctx); ok {
-		recordSql.List = append(recordSql.List, RecordSqlItem{Sql: sql, Args: args})
-	}
 }
@@ -0,0 +1,160 @@
+package tgsqlmetrics
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+// Collector 接收语句执行和连接池状态, Snapshot返回聚合后的统计用于导出
+type Collector interface {
+	ObserveStmt(info *tgsql.StmtInfo)
+	ObservePool(name string, stats sql.DBStats)
+	Snapshot() ([]StmtStats, []PoolStats)
+}
+
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type StmtKey struct {
+	DaoType  string
+	FuncName string
+	Action   tgsql.StmtAction
+}
+
+type StmtStats struct {
+	StmtKey
+	Calls  uint64
+	Errors uint64
+	// query返回的行数, exec和批量执行影响的行数
+	Rows uint64
+	// 耗时直方图, Buckets[i]为耗时小于等于Bounds[i]秒的次数(累计)
+	Bounds  []float64
+	Buckets []uint64
+	Sum     float64
+}
+
+type PoolStats struct {
+	// 默认数据源的名称为空字符串
+	Name string
+	sql.DBStats
+}
+
+// MemoryCollector 在内存中聚合的Collector, 可以通过WritePrometheus导出
+type MemoryCollector struct {
+	mu     sync.Mutex
+	bounds []float64
+	stmts  map[StmtKey]*StmtStats
+	pools  map[string]sql.DBStats
+}
+
+func NewMemoryCollector(buckets ...float64) *MemoryCollector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	return &MemoryCollector{
+		bounds: bounds,
+		stmts:  make(map[StmtKey]*StmtStats),
+		pools:  make(map[string]sql.DBStats),
+	}
+}
+
+func (c *MemoryCollector) ObserveStmt(info *tgsql.StmtInfo) {
+	key := StmtKey{DaoType: info.DaoType, FuncName: info.FuncName, Action: info.Action}
+	seconds := info.Duration.Seconds()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.stmts[key]
+	if !ok {
+		st = &StmtStats{StmtKey: key, Bounds: c.bounds, Buckets: make([]uint64, len(c.bounds))}
+		c.stmts[key] = st
+	}
+	st.Calls++
+	if info.Err != nil {
+		st.Errors++
+	}
+	if info.Action == tgsql.StmtQuery {
+		st.Rows += uint64(info.Rows)
+	} else {
+		st.Rows += uint64(info.RowsAffected)
+	}
+	st.Sum += seconds
+	for i, b := range st.Bounds {
+		if seconds <= b {
+			st.Buckets[i]++
+		}
+	}
+}
+
+func (c *MemoryCollector) ObservePool(name string, stats sql.DBStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pools[name] = stats
+}
+
+// Snapshot 返回按DAO和方法名排序的统计副本
+func (c *MemoryCollector) Snapshot() ([]StmtStats, []PoolStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stmts := make([]StmtStats, 0, len(c.stmts))
+	for _, st := range c.stmts {
+		cp := *st
+		cp.Buckets = append([]uint64(nil), st.Buckets...)
+		stmts = append(stmts, cp)
+	}
+	sort.Slice(stmts, func(i, j int) bool {
+		a, b := stmts[i].StmtKey, stmts[j].StmtKey
+		if a.DaoType != b.DaoType {
+			return a.DaoType < b.DaoType
+		}
+		if a.FuncName != b.FuncName {
+			return a.FuncName < b.FuncName
+		}
+		return a.Action < b.Action
+	})
+	pools := make([]PoolStats, 0, len(c.pools))
+	for name, stats := range c.pools {
+		pools = append(pools, PoolStats{Name: name, DBStats: stats})
+	}
+	sort.Slice(pools, func(i, j int) bool {
+		return pools[i].Name < pools[j].Name
+	})
+	return stmts, pools
+}
+
+// Interceptor 把每次语句执行的结果交给Collector, 批量执行只统计一次, 其中的prepare和exec不单独统计
+func Interceptor(c Collector) tgsql.Interceptor {
+	return func(ctx context.Context, info *tgsql.StmtInfo, next tgsql.Next) error {
+		err := next(ctx)
+		if !info.InBatch {
+			c.ObserveStmt(info)
+		}
+		return err
+	}
+}
+
+// WatchPools 按interval定期采集tdb中所有数据源的sql.DB.Stats(), 直到ctx结束
+func WatchPools(ctx context.Context, tdb *tgsql.TgenSql, c Collector, interval time.Duration) {
+	observe := func() {
+		tdb.RangeDB(func(name string, sqlDB *sql.DB) bool {
+			c.ObservePool(name, sqlDB.Stats())
+			return true
+		})
+	}
+	observe()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			observe()
+		}
+	}
+}
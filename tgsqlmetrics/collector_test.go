@@ -0,0 +1,69 @@
+package tgsqlmetrics
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	c := NewMemoryCollector(0.01, 0.1)
+	ic := Interceptor(c)
+	run := func(info *tgsql.StmtInfo, d time.Duration, err error) {
+		ic(context.Background(), info, func(ctx context.Context) error {
+			info.Duration = d
+			info.Err = err
+			return err
+		})
+	}
+	run(&tgsql.StmtInfo{DaoType: "dao.UserDB", FuncName: "List", Action: tgsql.StmtQuery, Rows: 3}, 5*time.Millisecond, nil)
+	run(&tgsql.StmtInfo{DaoType: "dao.UserDB", FuncName: "List", Action: tgsql.StmtQuery, Rows: 2}, 50*time.Millisecond, nil)
+	run(&tgsql.StmtInfo{DaoType: "dao.UserDB", FuncName: "Update", Action: tgsql.StmtExec}, time.Second, errors.New("lock wait timeout"))
+	// 批量执行只统计一次
+	batch := &tgsql.StmtInfo{DaoType: "dao.UserDB", FuncName: "BatchInsert", Action: tgsql.StmtBatch}
+	ic(context.Background(), batch, func(ctx context.Context) error {
+		run(&tgsql.StmtInfo{DaoType: "dao.UserDB", FuncName: "BatchInsert", Action: tgsql.StmtPrepare, InBatch: true}, time.Millisecond, nil)
+		for i := 0; i < 2; i++ {
+			run(&tgsql.StmtInfo{DaoType: "dao.UserDB", FuncName: "BatchInsert", Action: tgsql.StmtExec, InBatch: true, RowsAffected: 1}, time.Millisecond, nil)
+		}
+		batch.RowsAffected, batch.BatchRows, batch.Duration = 2, 2, 3*time.Millisecond
+		return nil
+	})
+	c.ObservePool("", sql.DBStats{MaxOpenConnections: 10, OpenConnections: 4, InUse: 1, Idle: 3})
+	c.ObservePool("default", sql.DBStats{OpenConnections: 2})
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE tgsql_stmt_calls_total counter\n",
+		`tgsql_stmt_calls_total{dao="dao.UserDB",func="List",action="query"} 2`,
+		`tgsql_stmt_errors_total{dao="dao.UserDB",func="Update",action="exec"} 1`,
+		`tgsql_stmt_rows_total{dao="dao.UserDB",func="List",action="query"} 5`,
+		`tgsql_stmt_duration_seconds_bucket{dao="dao.UserDB",func="List",action="query",le="0.01"} 1`,
+		`tgsql_stmt_duration_seconds_bucket{dao="dao.UserDB",func="List",action="query",le="0.1"} 2`,
+		`tgsql_stmt_duration_seconds_bucket{dao="dao.UserDB",func="Update",action="exec",le="+Inf"} 1`,
+		`tgsql_stmt_duration_seconds_count{dao="dao.UserDB",func="List",action="query"} 2`,
+		`tgsql_stmt_calls_total{dao="dao.UserDB",func="BatchInsert",action="batch"} 1`,
+		`tgsql_stmt_rows_total{dao="dao.UserDB",func="BatchInsert",action="batch"} 2`,
+		`tgsql_pool_open_connections{db=""} 4`,
+		`tgsql_pool_open_connections{db="default"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{`action="prepare"`, `func="BatchInsert",action="exec"`} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("output contains %q\n%s", unwanted, out)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package tgsqlmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WritePrometheus 以Prometheus文本格式输出统计数据, 默认数据源的db标签为空字符串
+func WritePrometheus(w io.Writer, c Collector) error {
+	stmts, pools := c.Snapshot()
+	bw := bufio.NewWriter(w)
+	header := func(name, typ, help string) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	stmtLabels := func(st *StmtStats) string {
+		return fmt.Sprintf(`dao="%s",func="%s",action="%s"`, escapeLabel(st.DaoType), escapeLabel(st.FuncName), escapeLabel(string(st.Action)))
+	}
+	counters := []struct {
+		name, help string
+		value      func(st *StmtStats) uint64
+	}{
+		{"tgsql_stmt_calls_total", "Number of executed statements.", func(st *StmtStats) uint64 { return st.Calls }},
+		{"tgsql_stmt_errors_total", "Number of failed statements.", func(st *StmtStats) uint64 { return st.Errors }},
+		{"tgsql_stmt_rows_total", "Number of rows returned or affected.", func(st *StmtStats) uint64 { return st.Rows }},
+	}
+	for _, counter := range counters {
+		if len(stmts) == 0 {
+			break
+		}
+		header(counter.name, "counter", counter.help)
+		for i := range stmts {
+			fmt.Fprintf(bw, "%s{%s} %d\n", counter.name, stmtLabels(&stmts[i]), counter.value(&stmts[i]))
+		}
+	}
+	if len(stmts) > 0 {
+		name := "tgsql_stmt_duration_seconds"
+		header(name, "histogram", "Statement execution latency.")
+		for i := range stmts {
+			st := &stmts[i]
+			labels := stmtLabels(st)
+			for j, b := range st.Bounds {
+				fmt.Fprintf(bw, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(b), st.Buckets[j])
+			}
+			fmt.Fprintf(bw, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, st.Calls)
+			fmt.Fprintf(bw, "%s_sum{%s} %s\n", name, labels, formatFloat(st.Sum))
+			fmt.Fprintf(bw, "%s_count{%s} %d\n", name, labels, st.Calls)
+		}
+	}
+	gauges := []struct {
+		name, typ, help string
+		value           func(p *PoolStats) string
+	}{
+		{"tgsql_pool_max_open_connections", "gauge", "Maximum number of open connections.", func(p *PoolStats) string { return strconv.Itoa(p.MaxOpenConnections) }},
+		{"tgsql_pool_open_connections", "gauge", "Number of established connections.", func(p *PoolStats) string { return strconv.Itoa(p.OpenConnections) }},
+		{"tgsql_pool_in_use_connections", "gauge", "Number of connections currently in use.", func(p *PoolStats) string { return strconv.Itoa(p.InUse) }},
+		{"tgsql_pool_idle_connections", "gauge", "Number of idle connections.", func(p *PoolStats) string { return strconv.Itoa(p.Idle) }},
+		{"tgsql_pool_wait_count_total", "counter", "Total number of connections waited for.", func(p *PoolStats) string { return strconv.FormatInt(p.WaitCount, 10) }},
+		{"tgsql_pool_wait_duration_seconds_total", "counter", "Total time blocked waiting for a new connection.", func(p *PoolStats) string { return formatFloat(p.WaitDuration.Seconds()) }},
+		{"tgsql_pool_max_idle_closed_total", "counter", "Total number of connections closed due to SetMaxIdleConns.", func(p *PoolStats) string { return strconv.FormatInt(p.MaxIdleClosed, 10) }},
+		{"tgsql_pool_max_idle_time_closed_total", "counter", "Total number of connections closed due to SetConnMaxIdleTime.", func(p *PoolStats) string { return strconv.FormatInt(p.MaxIdleTimeClosed, 10) }},
+		{"tgsql_pool_max_lifetime_closed_total", "counter", "Total number of connections closed due to SetConnMaxLifetime.", func(p *PoolStats) string { return strconv.FormatInt(p.MaxLifetimeClosed, 10) }},
+	}
+	for _, gauge := range gauges {
+		if len(pools) == 0 {
+			break
+		}
+		header(gauge.name, gauge.typ, gauge.help)
+		for i := range pools {
+			fmt.Fprintf(bw, "%s{db=\"%s\"} %s\n", gauge.name, escapeLabel(pools[i].Name), gauge.value(&pools[i]))
+		}
+	}
+	return bw.Flush()
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
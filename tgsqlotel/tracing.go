@@ -0,0 +1,103 @@
+package tgsqlotel
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tianxinzizhen/tgsql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/tianxinzizhen/tgsql/tgsqlotel"
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	dbSystem       string
+	attrs          []attribute.KeyValue
+}
+
+type Option func(c *config)
+
+// WithTracerProvider 指定TracerProvider, 默认使用otel.GetTracerProvider()
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// WithDBSystem 指定db.system属性, 默认为mysql
+func WithDBSystem(system string) Option {
+	return func(c *config) {
+		c.dbSystem = system
+	}
+}
+
+// WithAttributes 为所有span添加固定属性, 例如db.name
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(c *config) {
+		c.attrs = append(c.attrs, attrs...)
+	}
+}
+
+// Interceptor 为每次语句执行创建span, 批量执行中的prepare和exec作为批量span的子span
+//
+//	tdb.Use(tgsqlotel.Interceptor(tgsqlotel.WithDBSystem("mysql")))
+func Interceptor(opts ...Option) tgsql.Interceptor {
+	c := &config{
+		dbSystem: "mysql",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+	tracer := c.tracerProvider.Tracer(instrumentationName)
+	return func(ctx context.Context, info *tgsql.StmtInfo, next tgsql.Next) error {
+		ctx, span := tracer.Start(ctx, spanName(info), trace.WithSpanKind(trace.SpanKindClient))
+		defer span.End()
+		err := next(ctx)
+		attrs := append([]attribute.KeyValue{
+			attribute.String("db.system", c.dbSystem),
+			attribute.String("db.statement", info.Sql),
+			attribute.String("db.operation", operation(info.Sql)),
+			attribute.String("code.namespace", info.DaoType),
+			attribute.String("code.function", info.FuncName),
+			attribute.String("tgsql.action", string(info.Action)),
+		}, c.attrs...)
+		switch info.Action {
+		case tgsql.StmtQuery:
+			attrs = append(attrs, attribute.Int64("tgsql.rows", info.Rows))
+		case tgsql.StmtExec, tgsql.StmtBatch:
+			attrs = append(attrs, attribute.Int64("tgsql.rows_affected", info.RowsAffected))
+		}
+		span.SetAttributes(attrs...)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+func spanName(info *tgsql.StmtInfo) string {
+	name := info.FuncName
+	if i := strings.LastIndexByte(info.DaoType, '/'); i >= 0 {
+		name = info.DaoType[i+1:] + "." + name
+	} else if info.DaoType != "" {
+		name = info.DaoType + "." + name
+	}
+	return name + " " + string(info.Action)
+}
+
+// operation 取sql的第一个关键字作为db.operation
+func operation(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if i := strings.IndexAny(sql, " \t\r\n("); i > 0 {
+		sql = sql[:i]
+	}
+	return strings.ToUpper(sql)
+}
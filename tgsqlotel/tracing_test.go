@@ -0,0 +1,104 @@
+package tgsqlotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+	"github.com/tianxinzizhen/tgsql/tgsqltest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInterceptorBatchSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ic := Interceptor(WithTracerProvider(tp))
+
+	batch := &tgsql.StmtInfo{DaoType: "example.com/dao.UserDB", FuncName: "BatchInsert", Action: tgsql.StmtBatch}
+	err := ic(context.Background(), batch, func(ctx context.Context) error {
+		exec := &tgsql.StmtInfo{DaoType: batch.DaoType, FuncName: batch.FuncName, Action: tgsql.StmtExec, Sql: "insert into user values(?)", RowsAffected: 1}
+		if err := ic(ctx, exec, func(ctx context.Context) error { return nil }); err != nil {
+			return err
+		}
+		failed := &tgsql.StmtInfo{DaoType: batch.DaoType, FuncName: batch.FuncName, Action: tgsql.StmtExec, Sql: "insert into user values(?)"}
+		return ic(ctx, failed, func(ctx context.Context) error { return errors.New("duplicate entry") })
+	})
+	if err == nil {
+		t.Fatal("expected batch error")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("spans = %d, want 3", len(spans))
+	}
+	parent := spans[2]
+	if parent.Name != "dao.UserDB.BatchInsert batch" {
+		t.Errorf("parent span name = %q", parent.Name)
+	}
+	for _, child := range spans[:2] {
+		if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Errorf("span %q is not a child of the batch span", child.Name)
+		}
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["db.system"].AsString() != "mysql" || attrs["db.operation"].AsString() != "INSERT" || attrs["code.function"].AsString() != "BatchInsert" {
+		t.Errorf("unexpected attributes %v", spans[0].Attributes)
+	}
+	if spans[1].Status.Code != codes.Error || len(spans[1].Events) == 0 {
+		t.Errorf("error not recorded on span: %+v", spans[1].Status)
+	}
+}
+
+type userDB struct {
+	Get func(ctx context.Context, id int) (*user, error)
+}
+
+type user struct {
+	Id   int
+	Name string
+}
+
+const userDBSql = `package tgsqlotel
+
+type userDB struct {
+	//sql select id, name from user where id=@id
+	Get func(ctx context.Context, id int) (*user, error)
+}
+`
+
+func TestInterceptorDAO(t *testing.T) {
+	sqldb, mock := tgsqltest.NewT(t)
+	exporter := tracetest.NewInMemoryExporter()
+	tdb := tgsql.NewTgenSql(sqldb)
+	tdb.Use(Interceptor(WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))))
+	if err := tdb.LoadFuncDataInfoString(userDBSql); err != nil {
+		t.Fatal(err)
+	}
+	dao := &userDB{}
+	if err := tgsql.InitDBFunc(tdb, dao); err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(`select id, name from user where id=\?`).WithArgs(1).
+		WillReturnRows(tgsqltest.NewRows("id", "name").AddRow(1, "a"))
+	if u, err := dao.Get(context.Background(), 1); err != nil || u == nil || u.Name != "a" {
+		t.Fatalf("Get = %v, %v", u, err)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(spans))
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans[0].Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["db.operation"].AsString() != "SELECT" || attrs["tgsql.rows"].AsInt64() != 1 || attrs["code.function"].AsString() == "" {
+		t.Errorf("unexpected attributes %v", spans[0].Attributes)
+	}
+}
@@ -0,0 +1,213 @@
+package tgsqltest
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type conn struct {
+	mock *Mock
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if _, err := c.mock.next(kindPrepare, query, nil); err != nil {
+		return nil, err
+	}
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if _, err := c.mock.next(kindBegin, "", nil); err != nil {
+		return nil, err
+	}
+	return &tx{conn: c}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.mock.next(kindQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if e.rows == nil {
+		return &rows{Rows: NewRows()}, nil
+	}
+	return &rows{Rows: e.rows}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := c.mock.next(kindExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if e.result == nil {
+		return result{}, nil
+	}
+	return e.result, nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), toNamed(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), toNamed(args))
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func toNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+type tx struct {
+	conn *conn
+}
+
+func (t *tx) Commit() error {
+	_, err := t.conn.mock.next(kindCommit, "", nil)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	_, err := t.conn.mock.next(kindRollback, "", nil)
+	return err
+}
+
+// Rows 查询返回的列和数据, 列类型为数据库类型名称, 例如INT、VARCHAR、JSON
+type Rows struct {
+	columns []string
+	types   []string
+	values  [][]driver.Value
+	err     error
+}
+
+func NewRows(columns ...string) *Rows {
+	return &Rows{columns: columns}
+}
+
+// ColumnTypes 设置每一列的数据库类型名称, 没有设置时为空字符串
+func (r *Rows) ColumnTypes(types ...string) *Rows {
+	r.types = types
+	return r
+}
+
+// AddRow 添加一行数据, JSON列的字符串值会按照mysql驱动的方式以[]byte返回
+func (r *Rows) AddRow(values ...any) *Rows {
+	row := make([]driver.Value, len(r.columns))
+	for i := range row {
+		if i >= len(values) {
+			break
+		}
+		v, err := driver.DefaultParameterConverter.ConvertValue(values[i])
+		if err != nil {
+			r.err = err
+			return r
+		}
+		if s, ok := v.(string); ok && strings.EqualFold(r.typeName(i), "json") {
+			v = []byte(s)
+		}
+		row[i] = v
+	}
+	r.values = append(r.values, row)
+	return r
+}
+
+func (r *Rows) typeName(i int) string {
+	if i < len(r.types) {
+		return r.types[i]
+	}
+	return ""
+}
+
+type rows struct {
+	*Rows
+	pos int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.pos >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.pos])
+	r.pos++
+	return nil
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return strings.ToUpper(r.typeName(index))
+}
+
+// ColumnTypeScanType 使用第一个非空值的类型, 没有数据时按照数据库类型名称推断
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	for _, row := range r.values {
+		if row[index] != nil {
+			return reflect.TypeOf(row[index])
+		}
+	}
+	name := strings.ToUpper(r.typeName(index))
+	switch {
+	case strings.Contains(name, "INT"):
+		return reflect.TypeFor[int64]()
+	case strings.Contains(name, "FLOAT"), strings.Contains(name, "DOUBLE"), strings.Contains(name, "REAL"):
+		return reflect.TypeFor[float64]()
+	case strings.Contains(name, "BOOL"):
+		return reflect.TypeFor[bool]()
+	case strings.Contains(name, "DATE"), strings.Contains(name, "TIME"):
+		return reflect.TypeFor[time.Time]()
+	case strings.Contains(name, "CHAR"), strings.Contains(name, "TEXT"), strings.Contains(name, "DECIMAL"), strings.Contains(name, "NUMERIC"):
+		return reflect.TypeFor[string]()
+	case strings.Contains(name, "JSON"), strings.Contains(name, "BLOB"), strings.Contains(name, "BINARY"):
+		return reflect.TypeFor[[]byte]()
+	}
+	return reflect.TypeFor[any]()
+}
@@ -0,0 +1,98 @@
+package tgsqltest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tianxinzizhen/tgsql"
+)
+
+// UpdateGoldenEnv 设置为1时Golden重新生成golden文件
+const UpdateGoldenEnv = "TGSQL_UPDATE_GOLDEN"
+
+var contextType = reflect.TypeFor[context.Context]()
+
+// Fixtures DAO方法名到渲染参数的映射, 参数不包含context.Context
+type Fixtures map[string][]any
+
+// Golden 以dry-run方式渲染DAO结构体中的每一个方法, 与testdata/<结构体名>.<方法名>.golden比较,
+// 设置环境变量TGSQL_UPDATE_GOLDEN=1运行测试重新生成golden文件. 没有提供fixture的方法使用参数类型的零值渲染, 指针参数使用新分配的零值.
+func Golden(t testing.TB, tdb *tgsql.TgenSql, dao any, fixtures Fixtures) {
+	t.Helper()
+	dv := reflect.ValueOf(dao)
+	for dv.Kind() == reflect.Pointer {
+		dv = dv.Elem()
+	}
+	if dv.Kind() != reflect.Struct {
+		t.Fatalf("tgsqltest: golden dao %T is not a struct", dao)
+	}
+	dt := dv.Type()
+	for i := 0; i < dt.NumField(); i++ {
+		field := dt.Field(i)
+		if field.Type.Kind() != reflect.Func || !field.IsExported() {
+			continue
+		}
+		args, ok := fixtures[field.Name]
+		if !ok {
+			args = zeroArgs(field.Type)
+		}
+		items, err := tdb.RenderAll(context.Background(), dv.Field(i).Interface(), args...)
+		if err != nil {
+			t.Errorf("tgsqltest: render %s.%s: %v", dt.Name(), field.Name, err)
+			continue
+		}
+		var buf bytes.Buffer
+		for j, item := range items {
+			if len(items) > 1 {
+				fmt.Fprintf(&buf, "-- statement %d --\n", j)
+			}
+			fmt.Fprintf(&buf, "-- sql --\n%s\n-- args --\n", item.Sql)
+			for _, arg := range item.Args {
+				fmt.Fprintf(&buf, "%T %#v\n", arg, arg)
+			}
+		}
+		compareGolden(t, filepath.Join("testdata", dt.Name()+"."+field.Name+".golden"), buf.Bytes())
+	}
+}
+
+func zeroArgs(ft reflect.Type) []any {
+	var args []any
+	for i := 0; i < ft.NumIn(); i++ {
+		it := ft.In(i)
+		if it.Implements(contextType) {
+			continue
+		}
+		if it.Kind() == reflect.Pointer {
+			args = append(args, reflect.New(it.Elem()).Interface())
+		} else {
+			args = append(args, reflect.Zero(it).Interface())
+		}
+	}
+	return args
+}
+
+func compareGolden(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if os.Getenv(UpdateGoldenEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("tgsqltest: read golden file %s: %v (run go test with TGSQL_UPDATE_GOLDEN=1 to create it)", path, err)
+		return
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("tgsqltest: %s mismatch (run go test with TGSQL_UPDATE_GOLDEN=1 to rewrite it)\n--- want\n%s\n--- got\n%s", path, want, got)
+	}
+}
@@ -0,0 +1,310 @@
+package tgsqltest
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+const DriverName = "tgsqltest"
+
+type fakeDriver struct {
+	mu    sync.Mutex
+	mocks map[string]*Mock
+}
+
+var (
+	fake   = &fakeDriver{mocks: make(map[string]*Mock)}
+	dsnSeq atomic.Int64
+)
+
+func init() {
+	sql.Register(DriverName, fake)
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	m, ok := d.mocks[dsn]
+	if !ok {
+		return nil, fmt.Errorf("tgsqltest: unknown dsn %s", dsn)
+	}
+	return &conn{mock: m}, nil
+}
+
+type expectKind string
+
+const (
+	kindQuery    expectKind = "query"
+	kindExec     expectKind = "exec"
+	kindPrepare  expectKind = "prepare"
+	kindBegin    expectKind = "begin"
+	kindCommit   expectKind = "commit"
+	kindRollback expectKind = "rollback"
+)
+
+// Argument 自定义参数匹配
+type Argument interface {
+	Match(v driver.Value) bool
+}
+
+type anyArg struct{}
+
+func (anyArg) Match(driver.Value) bool {
+	return true
+}
+
+// AnyArg 匹配任意参数值
+func AnyArg() Argument {
+	return anyArg{}
+}
+
+// Expectation 一次期望的数据库调用, 按声明顺序匹配
+type Expectation struct {
+	kind      expectKind
+	sql       string
+	exact     bool
+	args      []any
+	checkArgs bool
+	rows      *Rows
+	result    driver.Result
+	err       error
+	triggered bool
+	re        *regexp.Regexp
+}
+
+// Exact 使用完整的sql文本匹配(忽略连续空白的差异), 默认sql作为正则表达式匹配
+func (e *Expectation) Exact() *Expectation {
+	e.exact = true
+	return e
+}
+
+func (e *Expectation) WithArgs(args ...any) *Expectation {
+	e.args = args
+	e.checkArgs = true
+	return e
+}
+
+func (e *Expectation) WillReturnRows(rows *Rows) *Expectation {
+	e.rows = rows
+	return e
+}
+
+func (e *Expectation) WillReturnResult(lastInsertId, rowsAffected int64) *Expectation {
+	e.result = result{lastInsertId: lastInsertId, rowsAffected: rowsAffected}
+	return e
+}
+
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) String() string {
+	if e.sql == "" {
+		return string(e.kind)
+	}
+	return fmt.Sprintf("%s %q", e.kind, e.sql)
+}
+
+func normalizeSql(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+func (e *Expectation) matchSql(query string) (bool, error) {
+	if e.exact {
+		return normalizeSql(e.sql) == normalizeSql(query), nil
+	}
+	if e.re == nil {
+		re, err := regexp.Compile(e.sql)
+		if err != nil {
+			return false, fmt.Errorf("tgsqltest: expectation %s is not a valid regexp, use Exact() to match the sql text: %w", e, err)
+		}
+		e.re = re
+	}
+	return e.re.MatchString(query), nil
+}
+
+func (e *Expectation) matchArgs(args []driver.NamedValue) error {
+	if !e.checkArgs {
+		return nil
+	}
+	if len(args) != len(e.args) {
+		return fmt.Errorf("args %v, want %v", namedValues(args), e.args)
+	}
+	for i, want := range e.args {
+		got := args[i].Value
+		if m, ok := want.(Argument); ok {
+			if !m.Match(got) {
+				return fmt.Errorf("arg(%d) %v not match", i, got)
+			}
+			continue
+		}
+		wv, err := driver.DefaultParameterConverter.ConvertValue(want)
+		if err != nil {
+			return fmt.Errorf("arg(%d) expected value %v: %w", i, want, err)
+		}
+		if !reflect.DeepEqual(wv, got) {
+			if wb, ok := wv.(string); ok {
+				if gb, ok := got.([]byte); ok && string(gb) == wb {
+					continue
+				}
+			}
+			return fmt.Errorf("arg(%d) %#v, want %#v", i, got, wv)
+		}
+	}
+	return nil
+}
+
+func namedValues(args []driver.NamedValue) []any {
+	vals := make([]any, len(args))
+	for i, a := range args {
+		vals[i] = a.Value
+	}
+	return vals
+}
+
+// Mock 假数据库, 记录期望并在调用时按顺序匹配
+type Mock struct {
+	mu           sync.Mutex
+	dsn          string
+	expectations []*Expectation
+}
+
+// New 创建注册在假驱动上的*sql.DB和对应的Mock, 使用完后调用Mock.Close取消注册, 测试中可以使用NewT
+func New() (*sql.DB, *Mock, error) {
+	dsn := fmt.Sprintf("tgsqltest_%d", dsnSeq.Add(1))
+	m := &Mock{dsn: dsn}
+	fake.mu.Lock()
+	fake.mocks[dsn] = m
+	fake.mu.Unlock()
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		m.Close()
+		return nil, nil, err
+	}
+	return db, m, nil
+}
+
+// NewT 与New相同, 测试结束时自动关闭*sql.DB并取消Mock的注册
+func NewT(t testing.TB) (*sql.DB, *Mock) {
+	t.Helper()
+	db, m, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		m.Close()
+	})
+	return db, m
+}
+
+// Close 从假驱动上取消注册, 之后不能再打开新的连接
+func (m *Mock) Close() error {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	delete(fake.mocks, m.dsn)
+	return nil
+}
+
+func (m *Mock) expect(kind expectKind, sql string) *Expectation {
+	e := &Expectation{kind: kind, sql: sql}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+func (m *Mock) ExpectQuery(sql string) *Expectation {
+	return m.expect(kindQuery, sql)
+}
+
+func (m *Mock) ExpectExec(sql string) *Expectation {
+	return m.expect(kindExec, sql)
+}
+
+// ExpectPrepare 期望一次prepare, 没有声明时prepare总是成功且不消耗期望
+func (m *Mock) ExpectPrepare(sql string) *Expectation {
+	return m.expect(kindPrepare, sql)
+}
+
+func (m *Mock) ExpectBegin() *Expectation {
+	return m.expect(kindBegin, "")
+}
+
+func (m *Mock) ExpectCommit() *Expectation {
+	return m.expect(kindCommit, "")
+}
+
+func (m *Mock) ExpectRollback() *Expectation {
+	return m.expect(kindRollback, "")
+}
+
+// ExpectationsWereMet 检查所有期望都已经被调用
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs []error
+	for _, e := range m.expectations {
+		if !e.triggered {
+			errs = append(errs, fmt.Errorf("tgsqltest: expectation %s was not met", e))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// next 匹配下一个未触发的期望
+func (m *Mock) next(kind expectKind, query string, args []driver.NamedValue) (*Expectation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.expectations {
+		if e.triggered {
+			continue
+		}
+		if kind == kindPrepare && e.kind != kindPrepare {
+			return nil, nil
+		}
+		if e.kind != kind {
+			return nil, fmt.Errorf("tgsqltest: call %s %q, next expectation is %s", kind, query, e)
+		}
+		if kind != kindBegin && kind != kindCommit && kind != kindRollback {
+			ok, err := e.matchSql(query)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, fmt.Errorf("tgsqltest: %s %q does not match %s", kind, query, e)
+			}
+		}
+		if err := e.matchArgs(args); err != nil {
+			return nil, fmt.Errorf("tgsqltest: %s %q %w", kind, query, err)
+		}
+		e.triggered = true
+		return e, e.err
+	}
+	if kind == kindPrepare {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("tgsqltest: unexpected %s %q %v", kind, query, namedValues(args))
+}
+
+type result struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertId, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
@@ -215,73 +215,78 @@ func InterpolateParams(query string, args []any, sqlEscapeBytesBackslash bool) (
 		buf = append(buf, query[i:i+q]...)
 		i += q
 
-		arg := args[argPos]
+		if argPos >= len(args) {
+			return "", fmt.Errorf("sql placeholder count is more than args(%d)", len(args))
+		}
+		buf, err = AppendParam(buf, args[argPos], sqlEscapeBytesBackslash)
+		if err != nil {
+			return "", err
+		}
 		argPos++
+	}
+	return string(buf), nil
+}
 
-		if arg == nil {
-			buf = append(buf, "NULL"...)
-			continue
+// AppendParam 将参数转换为sql字面量追加到buf
+func AppendParam(buf []byte, arg any, sqlEscapeBytesBackslash bool) (_ []byte, err error) {
+	if arg == nil {
+		return append(buf, "NULL"...), nil
+	}
+	switch v := arg.(type) {
+	case int64:
+		buf = strconv.AppendInt(buf, v, 10)
+	case uint64:
+		// Handle uint64 explicitly because our custom ConvertValue emits unsigned values
+		buf = strconv.AppendUint(buf, v, 10)
+	case float64:
+		buf = strconv.AppendFloat(buf, v, 'g', -1, 64)
+	case bool:
+		if v {
+			buf = append(buf, '1')
+		} else {
+			buf = append(buf, '0')
 		}
-		switch v := arg.(type) {
-		case int64:
-			buf = strconv.AppendInt(buf, v, 10)
-		case uint64:
-			// Handle uint64 explicitly because our custom ConvertValue emits unsigned values
-			buf = strconv.AppendUint(buf, v, 10)
-		case float64:
-			buf = strconv.AppendFloat(buf, v, 'g', -1, 64)
-		case bool:
-			if v {
-				buf = append(buf, '1')
-			} else {
-				buf = append(buf, '0')
-			}
-		case time.Time:
-			if v.IsZero() {
-				buf = append(buf, "'0000-00-00'"...)
-			} else {
-				buf = append(buf, '\'')
-				buf, err = appendDateTime(buf, v.In(time.Local))
-				if err != nil {
-					return "", err
-				}
-				buf = append(buf, '\'')
+	case time.Time:
+		if v.IsZero() {
+			buf = append(buf, "'0000-00-00'"...)
+		} else {
+			buf = append(buf, '\'')
+			buf, err = appendDateTime(buf, v.In(time.Local))
+			if err != nil {
+				return nil, err
 			}
-		case json.RawMessage:
 			buf = append(buf, '\'')
+		}
+	case json.RawMessage:
+		buf = append(buf, '\'')
+		if sqlEscapeBytesBackslash {
+			buf = escapeBytesBackslash(buf, v)
+		} else {
+			buf = escapeBytesQuotes(buf, v)
+		}
+		buf = append(buf, '\'')
+	case []byte:
+		if v == nil {
+			buf = append(buf, "NULL"...)
+		} else {
+			buf = append(buf, "_binary'"...)
 			if sqlEscapeBytesBackslash {
 				buf = escapeBytesBackslash(buf, v)
 			} else {
 				buf = escapeBytesQuotes(buf, v)
 			}
 			buf = append(buf, '\'')
-		case []byte:
-			if v == nil {
-				buf = append(buf, "NULL"...)
-			} else {
-				buf = append(buf, "_binary'"...)
-				if sqlEscapeBytesBackslash {
-					buf = escapeBytesBackslash(buf, v)
-				} else {
-					buf = escapeBytesQuotes(buf, v)
-				}
-				buf = append(buf, '\'')
-			}
-		case string:
-			buf = append(buf, '\'')
-			if sqlEscapeBytesBackslash {
-				buf = escapeStringBackslash(buf, v)
-			} else {
-				buf = escapeStringQuotes(buf, v)
-			}
-			buf = append(buf, '\'')
-		default:
-			if v == nil {
-				buf = append(buf, "NULL"...)
-			} else {
-				buf = append(buf, fmt.Sprintf("%v", v)...)
-			}
 		}
+	case string:
+		buf = append(buf, '\'')
+		if sqlEscapeBytesBackslash {
+			buf = escapeStringBackslash(buf, v)
+		} else {
+			buf = escapeStringQuotes(buf, v)
+		}
+		buf = append(buf, '\'')
+	default:
+		buf = append(buf, fmt.Sprintf("%v", v)...)
 	}
-	return string(buf), nil
+	return buf, nil
 }